@@ -0,0 +1,82 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/service/applicationautoscaling"
+	"github.com/aws/aws-sdk-go/service/applicationautoscaling/applicationautoscalingiface"
+)
+
+// EnableAutoScaling has Application Auto Scaling keep the provisioned
+// read and write capacity of the table holding v between their min and
+// max units, tracking targetPct percent utilization of each. The table
+// must exist, having been created with provisioned throughput, and its
+// name resolves as TableName does. A scalable target and a target
+// tracking policy are registered for each of read and write capacity,
+// replacing any registered before.
+//
+// svc is usually an *applicationautoscaling.ApplicationAutoScaling, the
+// interface allows a stub.
+func EnableAutoScaling(svc applicationautoscalingiface.ApplicationAutoScalingAPI, v interface{}, minR, maxR, minW, maxW int64, targetPct float64) error {
+	tn, err := std.tableName(reflect.TypeOf(v))
+	if err != nil {
+		return err
+	}
+	if err := checkScalingRange("read", minR, maxR); err != nil {
+		return err
+	}
+	if err := checkScalingRange("write", minW, maxW); err != nil {
+		return err
+	}
+	rid := "table/" + tn
+	ns := applicationautoscaling.ServiceNamespaceDynamodb
+	for _, d := range []struct {
+		dim, metric, name string
+		min, max          int64
+	}{
+		{applicationautoscaling.ScalableDimensionDynamodbTableReadCapacityUnits,
+			applicationautoscaling.MetricTypeDynamoDbreadCapacityUtilization, tn + "-read-scaling", minR, maxR},
+		{applicationautoscaling.ScalableDimensionDynamodbTableWriteCapacityUnits,
+			applicationautoscaling.MetricTypeDynamoDbwriteCapacityUtilization, tn + "-write-scaling", minW, maxW},
+	} {
+		d := d
+		if _, err := svc.RegisterScalableTarget(&applicationautoscaling.RegisterScalableTargetInput{
+			ServiceNamespace:  &ns,
+			ResourceId:        &rid,
+			ScalableDimension: &d.dim,
+			MinCapacity:       &d.min,
+			MaxCapacity:       &d.max,
+		}); err != nil {
+			return err
+		}
+		pt := applicationautoscaling.PolicyTypeTargetTrackingScaling
+		if _, err := svc.PutScalingPolicy(&applicationautoscaling.PutScalingPolicyInput{
+			PolicyName:        &d.name,
+			PolicyType:        &pt,
+			ServiceNamespace:  &ns,
+			ResourceId:        &rid,
+			ScalableDimension: &d.dim,
+			TargetTrackingScalingPolicyConfiguration: &applicationautoscaling.TargetTrackingScalingPolicyConfiguration{
+				TargetValue: &targetPct,
+				PredefinedMetricSpecification: &applicationautoscaling.PredefinedMetricSpecification{
+					PredefinedMetricType: &d.metric,
+				},
+			},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fails with a ScalingRangeError unless 1 <= min <= max
+func checkScalingRange(capacity string, min, max int64) error {
+	if min < 1 || max < min {
+		return &ScalingRangeError{capacity, min, max}
+	}
+	return nil
+}
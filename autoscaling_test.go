@@ -0,0 +1,44 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEnableAutoScaling(t *testing.T) {
+	s := &stubAutoScaling{}
+	if err := EnableAutoScaling(s, usr0, 5, 50, 1, 10, 70); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if len(s.targets) != 2 || len(s.policies) != 2 {
+		t.Fatalf("failed: %d targets, %d policies", len(s.targets), len(s.policies))
+	}
+	rid := "table/" + TableName(reflect.TypeOf(usr0))
+	r, w := s.targets[0], s.targets[1]
+	if *r.ResourceId != rid || *r.ScalableDimension != "dynamodb:table:ReadCapacityUnits" ||
+		*r.MinCapacity != 5 || *r.MaxCapacity != 50 {
+		t.Errorf("failed: read target %v", r)
+	}
+	if *w.ResourceId != rid || *w.ScalableDimension != "dynamodb:table:WriteCapacityUnits" ||
+		*w.MinCapacity != 1 || *w.MaxCapacity != 10 {
+		t.Errorf("failed: write target %v", w)
+	}
+	for n, metric := range []string{"DynamoDBReadCapacityUtilization", "DynamoDBWriteCapacityUtilization"} {
+		p := s.policies[n]
+		c := p.TargetTrackingScalingPolicyConfiguration
+		if *p.ResourceId != rid || *p.ScalableDimension != *s.targets[n].ScalableDimension ||
+			*c.TargetValue != 70 || *c.PredefinedMetricSpecification.PredefinedMetricType != metric {
+			t.Errorf("failed: policy %v", p)
+		}
+	}
+
+	var sre *ScalingRangeError
+	if err := EnableAutoScaling(&stubAutoScaling{}, usr0, 5, 4, 1, 10, 70); !errors.As(err, &sre) || sre.Capacity != "read" {
+		t.Errorf("failed: expected ScalingRangeError, got %v", err)
+	}
+}
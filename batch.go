@@ -0,0 +1,315 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// MaxBatchWriteItems is the most requests a BatchWriteItem call may hold
+const MaxBatchWriteItems = 25
+
+// MaxBatchGetItems is the most keys a BatchGetItem call may hold
+const MaxBatchGetItems = 100
+
+// MaxBatchAttempts is the most times BatchWriteAll and BatchGetAll submit
+// a chunk by default, the first attempt included, before giving up on
+// its unprocessed requests. See RetryPolicy.
+const MaxBatchAttempts = 8
+
+// the default wait before the first retry of unprocessed requests,
+// doubled for each retry after it
+var batchBackoff = 50 * time.Millisecond
+
+// WriteRequests marshals each of items (structs of any mix of types) into
+// a PutRequest, grouped by the name of the table it belongs in. The
+// requests are not split into BatchWriteItem sized chunks, callers can
+// chunk and submit them however suits, keeping the order given within
+// each table. Encoding failures are returned rather than panicking.
+func WriteRequests(items ...interface{}) (map[string][]*dynamodb.WriteRequest, error) {
+	rs := make(map[string][]*dynamodb.WriteRequest)
+	for _, i := range items {
+		pi, err := std.marshal(i)
+		if err != nil {
+			return nil, err
+		}
+		rs[*pi.TableName] = append(rs[*pi.TableName],
+			&dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{Item: pi.Item}})
+	}
+	return rs, nil
+}
+
+// the WriteRequests of the elements of the slice v by table. When they
+// are all structs (or non-nil pointers to structs) of one type, their
+// encoding is planned once for the type rather than worked out again for
+// each element.
+func sliceWriteRequests(v reflect.Value) (rs map[string][]*dynamodb.WriteRequest, err error) {
+	t, ok := elemStructType(v)
+	if !ok {
+		is := make([]interface{}, v.Len())
+		for n := range is {
+			is[n] = v.Index(n).Interface()
+		}
+		return WriteRequests(is...)
+	}
+	defer recoverError(&err)
+	tn := std.TableName(t)
+	p := newEncodePlan(t, std.namer)
+	p.cipher = std.cipher
+	p.ttl = std.ttl
+	p.encoded = std.encoded
+	ws := make([]*dynamodb.WriteRequest, v.Len())
+	for n := range ws {
+		ev := reflect.Indirect(v.Index(n))
+		if ev.Kind() == reflect.Interface {
+			ev = reflect.Indirect(ev.Elem())
+		}
+		ws[n] = &dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{Item: p.encode(ev)}}
+	}
+	return map[string][]*dynamodb.WriteRequest{tn: ws}, nil
+}
+
+// the struct type of every element of v, if they share one
+func elemStructType(v reflect.Value) (reflect.Type, bool) {
+	var t reflect.Type
+	for n := 0; n < v.Len(); n++ {
+		ev := v.Index(n)
+		if ev.Kind() == reflect.Interface {
+			ev = ev.Elem()
+		}
+		if ev.Kind() == reflect.Ptr {
+			if ev.IsNil() {
+				return nil, false
+			}
+			ev = ev.Elem()
+		}
+		if ev.Kind() != reflect.Struct || (t != nil && ev.Type() != t) {
+			return nil, false
+		}
+		t = ev.Type()
+	}
+	return t, t != nil
+}
+
+// BatchWriteItems marshals the elements of the slice items into
+// BatchWriteItemInputs of at most MaxBatchWriteItems puts each. Elements
+// may be structs of several types (items is then usually an
+// []interface{}), each is put in the table named for its own type. Every
+// input writes to a single table, tables in order of name, and each
+// table's items keep the order given.
+func BatchWriteItems(items interface{}) ([]*dynamodb.BatchWriteItemInput, error) {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, &UnsupportedKindError{v.Kind()}
+	}
+	rs, err := sliceWriteRequests(v)
+	if err != nil {
+		return nil, err
+	}
+	tns := make([]string, 0, len(rs))
+	for tn := range rs {
+		tns = append(tns, tn)
+	}
+	sort.Strings(tns)
+	var ins []*dynamodb.BatchWriteItemInput
+	for _, tn := range tns {
+		for r := rs[tn]; len(r) > 0; {
+			n := len(r)
+			if n > MaxBatchWriteItems {
+				n = MaxBatchWriteItems
+			}
+			ins = append(ins, &dynamodb.BatchWriteItemInput{
+				RequestItems: map[string][]*dynamodb.WriteRequest{tn: r[:n]},
+			})
+			r = r[n:]
+		}
+	}
+	return ins, nil
+}
+
+// BatchWriteAll writes the elements of items as BatchWriteItems would
+// chunk them, submitting each chunk in turn. Requests dynamoDB returns as
+// UnprocessedItems (usually under throttling) are resubmitted after an
+// exponential backoff, until none remain or the chunk has been tried the
+// MaxAttempts of the RetryPolicy (see SetRetryPolicy), which is an
+// UnprocessedItemsError. The cancellation of ctx stops the writes and
+// returns ctx.Err().
+func BatchWriteAll(ctx context.Context, svc dynamodbiface.DynamoDBAPI, items interface{}) error {
+	return batchWriteAll(ctx, svc, items, nil)
+}
+
+// BatchWriteAllCapacity is BatchWriteAll which also returns the capacity
+// consumed by every attempt.
+func BatchWriteAllCapacity(ctx context.Context, svc dynamodbiface.DynamoDBAPI, items interface{}) (Capacity, error) {
+	c := make(Capacity)
+	return c, batchWriteAll(ctx, svc, items, c)
+}
+
+func batchWriteAll(ctx context.Context, svc dynamodbiface.DynamoDBAPI, items interface{}, c Capacity) error {
+	ins, err := BatchWriteItems(items)
+	if err != nil {
+		return err
+	}
+	for _, in := range ins {
+		if err := batchWrite(ctx, svc, in, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// submits in, then its unprocessed items, until all are written. The
+// consumed capacity is added up in c unless it's nil.
+func batchWrite(ctx context.Context, svc dynamodbiface.DynamoDBAPI, in *dynamodb.BatchWriteItemInput, c Capacity) error {
+	p := std.retryPolicy()
+	for attempt := 1; ; attempt++ {
+		if c != nil {
+			rcc := dynamodb.ReturnConsumedCapacityTotal
+			in.ReturnConsumedCapacity = &rcc
+		}
+		out, err := svc.BatchWriteItemWithContext(ctx, in)
+		if err != nil {
+			return err
+		}
+		if c != nil {
+			c.add(out.ConsumedCapacity...)
+		}
+		if len(out.UnprocessedItems) == 0 {
+			return nil
+		}
+		if attempt >= p.attempts() {
+			n := 0
+			for _, rs := range out.UnprocessedItems {
+				n += len(rs)
+			}
+			return &UnprocessedItemsError{n, attempt}
+		}
+		in = &dynamodb.BatchWriteItemInput{RequestItems: out.UnprocessedItems}
+		if err := p.wait(ctx, attempt); err != nil {
+			return err
+		}
+	}
+}
+
+// BatchGetAll reads the items with the keys of the elements of the slice
+// keys, structs of the element type of the slice out points to, in
+// BatchGetItem calls of at most MaxBatchGetItems keys. Keys dynamoDB
+// returns as UnprocessedKeys are retried with the backoff of
+// BatchWriteAll, and left unprocessed after the MaxAttempts of the
+// RetryPolicy are an UnprocessedItemsError. The items found are appended to out in the order
+// of their keys, so each can be matched to the key it was read by, keys
+// of missing items are skipped and repeated keys read once. The
+// cancellation of ctx stops the reads and returns ctx.Err().
+func BatchGetAll(ctx context.Context, svc dynamodbiface.DynamoDBAPI, keys interface{}, out interface{}) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return &InvalidDecodeError{reflect.TypeOf(out)}
+	}
+	et := rv.Elem().Type().Elem()
+	tn, err := std.tableName(et)
+	if err != nil {
+		return err
+	}
+	kv := reflect.ValueOf(keys)
+	if kv.Kind() != reflect.Slice && kv.Kind() != reflect.Array {
+		return &UnsupportedKindError{kv.Kind()}
+	}
+	kms := make([]map[string]*dynamodb.AttributeValue, 0, kv.Len())
+	seen := make(map[string]bool)
+	for n := 0; n < kv.Len(); n++ {
+		km, err := KeyMap(kv.Index(n).Interface())
+		if err != nil {
+			return err
+		}
+		if id := keyIdentity(km, km); !seen[id] {
+			seen[id] = true
+			kms = append(kms, km)
+		}
+	}
+	found := make(map[string]map[string]*dynamodb.AttributeValue)
+	for r := kms; len(r) > 0; {
+		n := len(r)
+		if n > MaxBatchGetItems {
+			n = MaxBatchGetItems
+		}
+		items, err := batchGet(ctx, svc, &dynamodb.BatchGetItemInput{
+			RequestItems: map[string]*dynamodb.KeysAndAttributes{tn: {Keys: r[:n]}},
+		})
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			found[keyIdentity(item, r[0])] = item
+		}
+		r = r[n:]
+	}
+	sv := rv.Elem()
+	for _, km := range kms {
+		item, ok := found[keyIdentity(km, km)]
+		if !ok {
+			continue
+		}
+		ev, err := newItem(item, et)
+		if err != nil {
+			return err
+		}
+		sv = reflect.Append(sv, ev)
+	}
+	rv.Elem().Set(sv)
+	return nil
+}
+
+// submits in, then its unprocessed keys, returning the items of every
+// response
+func batchGet(ctx context.Context, svc dynamodbiface.DynamoDBAPI, in *dynamodb.BatchGetItemInput) ([]map[string]*dynamodb.AttributeValue, error) {
+	var items []map[string]*dynamodb.AttributeValue
+	p := std.retryPolicy()
+	for attempt := 1; ; attempt++ {
+		out, err := svc.BatchGetItemWithContext(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		for _, is := range out.Responses {
+			items = append(items, is...)
+		}
+		if len(out.UnprocessedKeys) == 0 {
+			return items, nil
+		}
+		if attempt >= p.attempts() {
+			n := 0
+			for _, ka := range out.UnprocessedKeys {
+				n += len(ka.Keys)
+			}
+			return nil, &UnprocessedItemsError{n, attempt}
+		}
+		in = &dynamodb.BatchGetItemInput{RequestItems: out.UnprocessedKeys}
+		if err := p.wait(ctx, attempt); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// identifies an item (or key) by the values of the attributes of key
+func keyIdentity(item, key map[string]*dynamodb.AttributeValue) string {
+	ns := make([]string, 0, len(key))
+	for n := range key {
+		ns = append(ns, n)
+	}
+	sort.Strings(ns)
+	vs := make([]string, len(ns))
+	for i, n := range ns {
+		if av := item[n]; av != nil {
+			vs[i] = n + "=" + av.String()
+		}
+	}
+	return strings.Join(vs, "\x00")
+}
@@ -0,0 +1,247 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestWriteRequests(t *testing.T) {
+	rs, err := WriteRequests(usr0, &msg, usr1)
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if len(rs) != 2 {
+		t.Fatalf("failed: requests for %d tables, want 2", len(rs))
+	}
+	us := rs[TableName(reflect.TypeOf(usr0))]
+	if len(us) != 2 || *us[0].PutRequest.Item["UserId"].S != "1000" || *us[1].PutRequest.Item["UserId"].S != "2000" {
+		t.Errorf("failed: Usr requests %v", us)
+	}
+	ms := rs[TableName(reflect.TypeOf(msg))]
+	if len(ms) != 1 || *ms[0].PutRequest.Item["MessageId"].S != msg.Id {
+		t.Errorf("failed: Message requests %v", ms)
+	}
+	if _, err := WriteRequests(usr0, "not a struct"); err == nil {
+		t.Error("failed: expected error for a string item")
+	}
+}
+
+func TestBatchWriteItems(t *testing.T) {
+	items := []interface{}{msg}
+	for n := 0; n < 30; n++ {
+		items = append(items, Usr{Id: strconv.Itoa(n)})
+	}
+	ins, err := BatchWriteItems(items)
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	counts := make(map[string][]int)
+	for _, in := range ins {
+		if len(in.RequestItems) != 1 {
+			t.Errorf("failed: input for %d tables", len(in.RequestItems))
+		}
+		for tn, rs := range in.RequestItems {
+			counts[tn] = append(counts[tn], len(rs))
+		}
+	}
+	ut, mt := TableName(reflect.TypeOf(usr0)), TableName(reflect.TypeOf(msg))
+	if !reflect.DeepEqual(counts[ut], []int{25, 5}) || !reflect.DeepEqual(counts[mt], []int{1}) {
+		t.Errorf("failed: chunks %v", counts)
+	}
+	// order within a table is kept across chunks
+	var chunks [][]*dynamodb.WriteRequest
+	for _, in := range ins {
+		if rs, ok := in.RequestItems[ut]; ok {
+			chunks = append(chunks, rs)
+		}
+	}
+	if len(chunks) == 2 && *chunks[1][4].PutRequest.Item["UserId"].S != "29" {
+		t.Errorf("failed: last Usr %v", chunks[1][4].PutRequest.Item)
+	}
+	if _, err := BatchWriteItems(usr0); err == nil {
+		t.Error("failed: expected error for a non-slice")
+	}
+}
+
+func TestBatchWriteAllRetriesUnprocessed(t *testing.T) {
+	defer func(d time.Duration) { batchBackoff = d }(batchBackoff)
+	batchBackoff = time.Millisecond
+
+	rs, err := WriteRequests(usr1)
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	s := &stubDynamo{batchUnprocessed: []map[string][]*dynamodb.WriteRequest{rs}}
+	if err := BatchWriteAll(context.Background(), s, []Usr{usr0, usr1}); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if len(s.batchIn) != 2 {
+		t.Fatalf("failed: %d BatchWriteItem calls, want 2", len(s.batchIn))
+	}
+	retry := s.batchIn[1].RequestItems[TableName(reflect.TypeOf(usr1))]
+	if len(retry) != 1 || *retry[0].PutRequest.Item["UserId"].S != "2000" {
+		t.Errorf("failed: retried %v", s.batchIn[1].RequestItems)
+	}
+
+	s = &stubDynamo{}
+	for n := 0; n < MaxBatchAttempts; n++ {
+		s.batchUnprocessed = append(s.batchUnprocessed, rs)
+	}
+	err = BatchWriteAll(context.Background(), s, []Usr{usr1})
+	if e, ok := err.(*UnprocessedItemsError); !ok || e.Count != 1 || len(s.batchIn) != MaxBatchAttempts {
+		t.Errorf("failed: %v after %d calls", err, len(s.batchIn))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s = &stubDynamo{batchUnprocessed: []map[string][]*dynamodb.WriteRequest{rs}}
+	if err := BatchWriteAll(ctx, s, []Usr{usr1}); err != context.Canceled {
+		t.Errorf("failed: %v, want context.Canceled", err)
+	}
+}
+
+func TestBatchGetAllRetriesUnprocessed(t *testing.T) {
+	defer func(d time.Duration) { batchBackoff = d }(batchBackoff)
+	batchBackoff = time.Millisecond
+
+	s := &stubDynamo{
+		items: map[string][]map[string]*dynamodb.AttributeValue{
+			TableName(reflect.TypeOf(usr0)): {Marshal(usr0).Item, Marshal(usr1).Item},
+		},
+		batchGetUnprocessed: []int{1},
+	}
+	keys := []Usr{{Id: "2000"}, {Id: "3000"}, {Id: "1000"}, {Id: "2000"}}
+	var out []*Usr
+	if err := BatchGetAll(context.Background(), s, keys, &out); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if len(s.batchGetIn) != 2 {
+		t.Fatalf("failed: %d BatchGetItem calls, want 2", len(s.batchGetIn))
+	}
+	retry := s.batchGetIn[1].RequestItems[TableName(reflect.TypeOf(usr0))].Keys
+	if len(retry) != 1 || *retry[0]["UserId"].S != "1000" {
+		t.Errorf("failed: retried %v", retry)
+	}
+	if len(out) != 2 || out[0].Id != "2000" || out[1].Id != "1000" {
+		t.Errorf("failed: decoded %v", out)
+	}
+}
+
+func TestBatchWriteItemsPlannedMatchesGeneric(t *testing.T) {
+	users := []interface{}{usr0, &usr1, Usr{Id: "3000", Peers: []string{"1000"}}}
+	planned, err := BatchWriteItems(users)
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	generic, err := WriteRequests(users...)
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	tn := TableName(reflect.TypeOf(usr0))
+	if len(planned) != 1 || !reflect.DeepEqual(planned[0].RequestItems[tn], generic[tn]) {
+		t.Errorf("failed: planned requests differ from generic %v %v", planned, generic)
+	}
+	if _, err := BatchWriteItems([]*Usr{&usr0, nil}); err == nil {
+		t.Error("failed: expected error for a nil element")
+	}
+}
+
+func benchmarkUsers() []Usr {
+	us := make([]Usr, 1000)
+	for n := range us {
+		us[n] = Usr{Id: strconv.Itoa(n), Origin: "web", Email: "u@example.com", Peers: []string{"1", "2"}}
+	}
+	return us
+}
+
+func BenchmarkBatchWriteItems(b *testing.B) {
+	us := benchmarkUsers()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if _, err := BatchWriteItems(us); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWriteRequestsPerItem(b *testing.B) {
+	us := benchmarkUsers()
+	is := make([]interface{}, len(us))
+	for n := range us {
+		is[n] = us[n]
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if _, err := WriteRequests(is...); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	defer func() { std.retry = nil }()
+	SetRetryPolicy(RetryPolicy{MaxAttempts: 3})
+
+	rs, _ := WriteRequests(usr1)
+	s := &stubDynamo{}
+	for n := 0; n < 5; n++ {
+		s.batchUnprocessed = append(s.batchUnprocessed, rs)
+	}
+	err := BatchWriteAll(context.Background(), s, []Usr{usr1})
+	if e, ok := err.(*UnprocessedItemsError); !ok || e.Attempts != 3 || len(s.batchIn) != 3 {
+		t.Errorf("failed: %v after %d calls", err, len(s.batchIn))
+	}
+
+	throttled := awserr.New(dynamodb.ErrCodeProvisionedThroughputExceededException, "slow down", nil)
+	s = &stubDynamo{segmentErr: map[int64]error{0: throttled}}
+	var us []Usr
+	if err := ParallelScanAll(context.Background(), s, &us, 1); err == nil || len(s.scanIn) != 3 {
+		t.Errorf("failed: %v after %d scans", err, len(s.scanIn))
+	}
+
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, Jitter: 0.5}
+	if d := p.delay(1); d < 50*time.Millisecond || d > 100*time.Millisecond {
+		t.Errorf("failed: jittered delay %s", d)
+	}
+	if d := (RetryPolicy{BaseDelay: time.Second}).delay(3); d != 4*time.Second {
+		t.Errorf("failed: third delay %s", d)
+	}
+	for _, n := range []int{40, 64, 100, 1000} {
+		if d := (RetryPolicy{BaseDelay: time.Second}).delay(n); d != maxRetryDelay {
+			t.Errorf("failed: delay %d is %s", n, d)
+		}
+	}
+	if d := (RetryPolicy{BaseDelay: time.Second, MaxDelay: 3 * time.Second}).delay(5); d != 3*time.Second {
+		t.Errorf("failed: capped delay %s", d)
+	}
+}
+
+func TestBatchItemCallbacks(t *testing.T) {
+	var counts []int
+	std.OnItemEncoded(func(t reflect.Type, attrs int) { counts = append(counts, attrs) })
+	defer std.OnItemEncoded(nil)
+
+	if err := BatchWriteAll(context.Background(), &stubDynamo{}, []Usr{usr0, usr1}); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	planned := counts
+	counts = nil
+	if _, err := WriteRequests(usr0, usr1); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if len(planned) != 2 || !reflect.DeepEqual(planned, counts) {
+		t.Errorf("failed: planned callbacks %v, generic %v", planned, counts)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Capacity totals the capacity units consumed by a series of calls, by
+// table name. It is returned by the Capacity variants of the runners
+// (LoadCapacity, ScanAllCapacity, BatchWriteAllCapacity), which ask for
+// the TOTAL consumed capacity of each call. The plain runners ask for
+// none, sparing dynamoDB the accounting.
+type Capacity map[string]float64
+
+// Total is the capacity consumed across every table
+func (c Capacity) Total() float64 {
+	var t float64
+	for _, u := range c {
+		t += u
+	}
+	return t
+}
+
+func (c Capacity) add(ccs ...*dynamodb.ConsumedCapacity) {
+	for _, cc := range ccs {
+		if cc != nil && cc.TableName != nil && cc.CapacityUnits != nil {
+			c[*cc.TableName] += *cc.CapacityUnits
+		}
+	}
+}
+
+// checks the ReturnConsumedCapacity of a builder is one dynamoDB knows
+func checkConsumedCapacity(rcc string) error {
+	switch rcc {
+	case "", dynamodb.ReturnConsumedCapacityNone, dynamodb.ReturnConsumedCapacityTotal,
+		dynamodb.ReturnConsumedCapacityIndexes:
+		return nil
+	}
+	return &ReturnConsumedCapacityError{rcc}
+}
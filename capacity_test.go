@@ -0,0 +1,78 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestReturnConsumedCapacity(t *testing.T) {
+	total := dynamodb.ReturnConsumedCapacityTotal
+	gi, _ := Get(usr0).ReturnConsumedCapacity(total).Input()
+	pi, _ := Put(usr0).ReturnConsumedCapacity(total).Input()
+	ui, _ := Update(usr0).ReturnConsumedCapacity(total).Input()
+	di, _ := Delete(usr0).ReturnConsumedCapacity(total).Input()
+	si, _ := Scan(usr0).ReturnConsumedCapacity(total).Input()
+	qi, _ := Query(usr0).ReturnConsumedCapacity(total).Input()
+	for op, rcc := range map[string]*string{
+		"get": gi.ReturnConsumedCapacity, "put": pi.ReturnConsumedCapacity,
+		"update": ui.ReturnConsumedCapacity, "delete": di.ReturnConsumedCapacity,
+		"scan": si.ReturnConsumedCapacity, "query": qi.ReturnConsumedCapacity,
+	} {
+		if rcc == nil || *rcc != total {
+			t.Errorf("failed: %s ReturnConsumedCapacity %v", op, rcc)
+		}
+	}
+	if gi, _ := Get(usr0).Input(); gi.ReturnConsumedCapacity != nil {
+		t.Errorf("failed: default ReturnConsumedCapacity %s", *gi.ReturnConsumedCapacity)
+	}
+	if _, err := Put(usr0).ReturnConsumedCapacity("ALL").Input(); err == nil {
+		t.Error("failed: expected error for ReturnConsumedCapacity ALL")
+	}
+}
+
+func TestRunnerCapacity(t *testing.T) {
+	tn := TableName(reflect.TypeOf(usr0))
+	half, one := 0.5, 1.0
+	s := &stubDynamo{scanOut: []*dynamodb.ScanOutput{
+		{
+			Items:            []map[string]*dynamodb.AttributeValue{Marshal(usr0).Item},
+			LastEvaluatedKey: map[string]*dynamodb.AttributeValue{"UserId": {S: &usr0.Id}},
+			ConsumedCapacity: &dynamodb.ConsumedCapacity{TableName: &tn, CapacityUnits: &half},
+		},
+		{ConsumedCapacity: &dynamodb.ConsumedCapacity{TableName: &tn, CapacityUnits: &one}},
+	}}
+	var us []Usr
+	c, err := ScanAllCapacity(s, &us)
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if c[tn] != 1.5 || c.Total() != 1.5 || len(us) != 1 {
+		t.Errorf("failed: scan capacity %v, %d items", c, len(us))
+	}
+	if rcc := s.scanIn[0].ReturnConsumedCapacity; rcc == nil || *rcc != dynamodb.ReturnConsumedCapacityTotal {
+		t.Errorf("failed: scan ReturnConsumedCapacity %v", rcc)
+	}
+
+	defer func(d time.Duration) { batchBackoff = d }(batchBackoff)
+	batchBackoff = time.Millisecond
+	rs, _ := WriteRequests(usr1)
+	s = &stubDynamo{batchUnprocessed: []map[string][]*dynamodb.WriteRequest{rs}}
+	c, err = BatchWriteAllCapacity(context.Background(), s, []Usr{usr0, usr1})
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if c[tn] != 3 {
+		t.Errorf("failed: batch write capacity %v", c)
+	}
+	if err := BatchWriteAll(context.Background(), s, []Usr{usr0}); err != nil || s.batchIn[2].ReturnConsumedCapacity != nil {
+		t.Errorf("failed: plain BatchWriteAll asked for capacity %v", err)
+	}
+}
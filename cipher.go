@@ -0,0 +1,117 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// FieldCipher transforms the bytes of an encrypt field, encrypting them
+// when given to Encoder.SetFieldCipher and decrypting them when given to
+// Decoder.SetFieldCipher.
+type FieldCipher func(in []byte) ([]byte, error)
+
+var errNoFieldCipher = errors.New("no field cipher set")
+
+// fields with the option encrypt are stored as the cipher text of their
+// value, a B, see Encoder.SetFieldCipher
+func isEncrypted(s reflect.StructField) bool {
+	_, o := parseTag(s.Tag.Get("dynaGo"))
+	return o.Contains("encrypt")
+}
+
+// replaces the attributes of item encoded from the encrypt fields of the
+// struct type t with their cipher text
+func encryptFields(item map[string]*dynamodb.AttributeValue, t reflect.Type, namer func(string) string, c FieldCipher) {
+	for _, fs := range attrFields(t) {
+		if !isEncrypted(fs) {
+			continue
+		}
+		an := attrName(fs, namer)
+		if av, ok := item[an]; ok {
+			item[an] = encryptAttribute(fs, an, av, c)
+		}
+	}
+}
+
+// the B holding the cipher text of the scalar av, encoded from sf as
+// the attribute an
+func encryptAttribute(sf reflect.StructField, an string, av *dynamodb.AttributeValue, c FieldCipher) *dynamodb.AttributeValue {
+	var p []byte
+	switch {
+	case av.S != nil:
+		p = []byte(*av.S)
+	case av.N != nil:
+		p = []byte(*av.N)
+	case av.B != nil:
+		p = av.B
+	default:
+		panic(&TagOptionError{sf.Name, "encrypt"})
+	}
+	if c == nil {
+		panic(&FieldCipherError{an, errNoFieldCipher})
+	}
+	b, err := c(p)
+	if err != nil {
+		panic(&FieldCipherError{an, err})
+	}
+	return &dynamodb.AttributeValue{B: b}
+}
+
+// replaces the B attributes of m for encrypt fields with the attribute
+// their decrypted value would have been encoded as. m itself is not
+// modified.
+func decryptFields(m map[string]*dynamodb.AttributeValue, fields []field, c FieldCipher) map[string]*dynamodb.AttributeValue {
+	var dm map[string]*dynamodb.AttributeValue
+	for _, f := range fields {
+		av, ok := m[f.name]
+		if !f.encrypt || !ok || av.B == nil {
+			continue
+		}
+		if c == nil {
+			panic(&FieldCipherError{f.name, errNoFieldCipher})
+		}
+		p, err := c(av.B)
+		if err != nil {
+			panic(&FieldCipherError{f.name, err})
+		}
+		if dm == nil {
+			dm = make(map[string]*dynamodb.AttributeValue, len(m))
+			for an, av := range m {
+				dm[an] = av
+			}
+		}
+		dm[f.name] = plainAttribute(f, p)
+	}
+	if dm == nil {
+		return m
+	}
+	return dm
+}
+
+// the attribute of the plain text p of the encrypt field f
+func plainAttribute(f field, p []byte) *dynamodb.AttributeValue {
+	t := f.typ
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	s := string(p)
+	switch {
+	case t.Kind() == reflect.String || f.str || f.dur:
+		return &dynamodb.AttributeValue{S: &s}
+	case isBytes(t):
+		return &dynamodb.AttributeValue{B: p}
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return &dynamodb.AttributeValue{N: &s}
+	}
+	panic(&TagOptionError{f.name, "encrypt"})
+}
@@ -5,8 +5,14 @@
 package dynaGo
 
 import (
+	"math/big"
+	"net"
+	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
@@ -38,11 +44,100 @@ func (e UnsupportedArrayElementType) Error() string {
 	return "dynaGo: decoding " + e.Type.String() + " unimplemented"
 }
 
+type InvalidNumberDecodeError struct {
+	Value string
+	Type  reflect.Type
+}
+
+func (e InvalidNumberDecodeError) Error() string {
+	return "dynaGo: cannot decode number " + e.Value + " into " + e.Type.String()
+}
+
+type ListElementTypeError struct {
+	Index int
+	Type  reflect.Type
+	Value string
+}
+
+func (e ListElementTypeError) Error() string {
+	return "dynaGo: list element " + strconv.Itoa(e.Index) + " cannot be decoded into " +
+		e.Type.String() + ": " + e.Value
+}
+
+type ByteLengthError struct {
+	Length int
+	Type   reflect.Type
+}
+
+func (e ByteLengthError) Error() string {
+	return "dynaGo: cannot decode " + strconv.Itoa(e.Length) + " bytes into " + e.Type.String()
+}
+
+type AttributeTypeError struct {
+	Want  string
+	Found string
+	Type  reflect.Type
+}
+
+func (e AttributeTypeError) Error() string {
+	return "dynaGo: cannot decode " + e.Found + " attribute into " + e.Type.String() +
+		", expected " + e.Want
+}
+
+type FieldKindDecodeError struct {
+	Type      reflect.Type
+	FieldName string
+	Kind      reflect.Kind
+}
+
+func (e FieldKindDecodeError) Error() string {
+	return "dynaGo: cannot decode into field " + e.FieldName + " of " + e.Type.String() +
+		", " + e.Kind.String() + " is not supported"
+}
+
+type DefaultValueError struct {
+	Value string
+	Type  reflect.Type
+}
+
+func (e DefaultValueError) Error() string {
+	return "dynaGo: default " + e.Value + " cannot be decoded into " + e.Type.String()
+}
+
+type UnknownAttributeError struct {
+	Type          reflect.Type
+	AttributeName string
+}
+
+func (e UnknownAttributeError) Error() string {
+	return "dynaGo: attribute " + e.AttributeName + " matches no field of " + e.Type.String()
+}
+
 // Decode pulls structs (of type i interface{}) from
 // map[string]*dynamodb.AttributeValue, where  string is the
 // fieldname (or overriden by the dynaGo: fieldtag) and the
 // atributeValue is the value to be stored in the field.
+// Fields whose attribute is absent from the item, or NULL, are left
+// untouched, so optional pointers stay nil and values keep their zero
+// value. This includes attributes Marshal drops for being empty. A field
+// tagged with a default (`dynaGo:",default=unknown"`) is set to it
+// instead when the attribute is absent and the field is zero, so values
+// already in the struct are never replaced by defaults.
+//
+// Items decoded into a type with migrations (see RegisterMigration) are
+// migrated before any of this.
+//
+// The Keys, NewImage and OldImage of a dynamodbstreams.StreamRecord are
+// maps of *dynamodb.AttributeValue in this SDK, so stream images decode
+// with Unmarshal as they are, without conversion.
 func Unmarshal(m map[string]*dynamodb.AttributeValue, i interface{}) error {
+	return stdDecoder.Unmarshal(m, i)
+}
+
+// Unmarshal is the same as the package level Unmarshal, with attributes
+// named by dec.
+func (dec *Decoder) Unmarshal(m map[string]*dynamodb.AttributeValue, i interface{}) (err error) {
+	defer recoverError(&err)
 	rv := reflect.ValueOf(i)
 	if rv.Kind() != reflect.Ptr || rv.IsNil() {
 		return &InvalidDecodeError{rv.Type()}
@@ -52,29 +147,154 @@ func Unmarshal(m map[string]*dynamodb.AttributeValue, i interface{}) error {
 	if ev.Kind() != reflect.Struct {
 		return &OnlyStructsSupportedError{ev.Kind()}
 	}
-	for i, field := range typeFields(et) {
-		if av, ok := m[field.name]; ok {
-			f := ev.Field(i)
-			decoder(f.Type())(av, f)
+	m = migrate(m, et)
+	fields := dec.fields(et)
+	if dec.fold {
+		m = foldAttributes(m, fields.list)
+	}
+	m = decryptFields(m, fields.list, dec.cipher)
+	m = loosenNumbers(m, fields.list, dec.loose)
+	if dec.strict {
+		checkAttributes(m, et, fields)
+	}
+	dec.decodeFields(m, ev, fields.list)
+	if dec.decoded != nil {
+		n := 0
+		for _, f := range fields.list {
+			if av, ok := m[f.name]; ok && !isNull(av) {
+				n++
+			}
 		}
+		dec.decoded(et, n)
+	}
+	return nil
+}
+
+// UnmarshalValue decodes the single attribute av into the value out
+// points to, as a struct field of that type would be decoded. A NULL
+// attribute sets the value to its zero value. An attribute of another
+// type than the value takes is reported as an AttributeTypeError.
+func UnmarshalValue(av *dynamodb.AttributeValue, out interface{}) (err error) {
+	defer recoverError(&err)
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return &InvalidDecodeError{reflect.TypeOf(out)}
+	}
+	ev := rv.Elem()
+	if isNull(av) {
+		ev.Set(reflect.Zero(ev.Type()))
+		return nil
 	}
+	decoder(ev.Type())(av, ev)
 	return nil
 }
 
+// decodes the attributes of m into the fields of the struct rv, composing
+// any nil embedded struct pointers on the way to a field that is present.
+// Only the fields of attributes present in m are assigned, so rv may be
+// partly populated already, as when decoding a projection of an item.
+func (dec *Decoder) decodeFields(m map[string]*dynamodb.AttributeValue, rv reflect.Value, fields []field) {
+	for _, field := range fields {
+		av, ok := m[field.name]
+		if !ok && field.def != nil && isZeroField(rv, field.index) {
+			av, ok = field.defaultAttribute(), true
+		}
+		// absent and NULL attributes leave the field as it was, unless
+		// it captures the raw attribute
+		if ok && (!isNull(av) || field.typ == rawType) {
+			structCompose(rv, field.index[:len(field.index)-1])
+			f := rv.FieldByIndex(field.index)
+			switch f.Kind() {
+			case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+				panic(FieldKindDecodeError{rv.Type(), rv.Type().FieldByIndex(field.index).Name, f.Kind()})
+			}
+			if field.str {
+				stringIntDecoder(av, f)
+				continue
+			}
+			if field.shardSep != "" && av.S != nil {
+				s := unshardKey(*av.S, field.shardSep)
+				av = &dynamodb.AttributeValue{S: &s}
+			}
+			if field.dur {
+				if f.Kind() == reflect.Ptr {
+					(&ptrDecoder{durationStringDecoder}).decode(av, f)
+				} else {
+					durationStringDecoder(av, f)
+				}
+				continue
+			}
+			if field.boolSet {
+				boolSetMapDecoder(av, f)
+				continue
+			}
+			if field.ttl {
+				if f.Kind() == reflect.Ptr {
+					(&ptrDecoder{ttlTimeDecoder}).decode(av, f)
+				} else {
+					ttlTimeDecoder(av, f)
+				}
+				continue
+			}
+			dec.decoder(f.Type())(av, f)
+		}
+	}
+}
+
+// reports whether the field at the index path i of the struct rv holds
+// its zero value, or is out of reach behind a nil pointer
+func isZeroField(rv reflect.Value, i []int) bool {
+	fv, ok := fieldByIndex(rv, i)
+	return !ok || fv.IsZero()
+}
+
 func decoder(t reflect.Type) decoderFunc {
+	return stdDecoder.decoder(t)
+}
+
+// the decoderFunc of the type t, decoding the fields of nested structs as
+// dec decodes those of an item
+func (dec *Decoder) decoder(t reflect.Type) decoderFunc {
+	switch t {
+	case bigIntType:
+		return bigIntDecoder
+	case bigFloatType:
+		return bigFloatDecoder
+	case timeType:
+		return timeDecoder
+	case rawMapType:
+		return rawMapDecoder
+	case rawType:
+		return rawDecoder
+	case ipType:
+		return ipDecoder
+	case ipNetType:
+		return ipNetDecoder
+	case urlType:
+		return urlDecoder
+	}
+	if isSQLNull(t) {
+		return sqlNullDecoder
+	}
 	switch t.Kind() {
 	case reflect.String:
 		return stringDecoder
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		return intDecoder
+	case reflect.Float32, reflect.Float64:
+		return floatDecoder
+	case reflect.Bool:
+		return boolDecoder
 	case reflect.Ptr:
-		return newPtrDecoder(t)
+		return newPtrDecoder(dec, t)
 	case reflect.Map:
-		return newMapDecoder(t)
+		return newMapDecoder(dec, t)
 	case reflect.Struct:
-		return structDecoder
+		return dec.structDecoder
 	case reflect.Slice, reflect.Array:
-		return newSliceDecoder(t)
+		return newSliceDecoder(dec, t)
+	case reflect.Interface:
+		return interfaceDecoder
 	default:
 		return UnsupportedTypeDecoder
 	}
@@ -88,14 +308,226 @@ func UnsupportedTypeDecoder(av *dynamodb.AttributeValue, rv reflect.Value) {
 	panic(UnsupportedTypeDecoderError{rv.Type()})
 }
 func stringDecoder(av *dynamodb.AttributeValue, rv reflect.Value) {
+	expectType(av, rv, "S", av.S != nil)
 	rv.SetString(*av.S)
 }
 func intDecoder(av *dynamodb.AttributeValue, rv reflect.Value) {
+	expectType(av, rv, "N", av.N != nil)
 	n, _ := strconv.ParseInt(*av.N, 10, 64)
 	rv.SetInt(n)
 }
+
+// copies an M into a map[string]*dynamodb.AttributeValue undecoded
+func rawMapDecoder(av *dynamodb.AttributeValue, rv reflect.Value) {
+	expectType(av, rv, "M", av.M != nil)
+	m := make(map[string]*dynamodb.AttributeValue, len(av.M))
+	for k, a := range av.M {
+		m[k] = a
+	}
+	rv.Set(reflect.ValueOf(m).Convert(rv.Type()))
+}
+
+// decodes the value of a sql.Null type, making it Valid. An absent or
+// NULL attribute leaves it as it was, see sqlNullValueEncoder.
+func sqlNullDecoder(av *dynamodb.AttributeValue, rv reflect.Value) {
+	decoder(rv.Field(0).Type())(av, rv.Field(0))
+	rv.Field(1).SetBool(true)
+}
+
+// captures the attribute itself, of any type, see rawValueEncoder
+func rawDecoder(av *dynamodb.AttributeValue, rv reflect.Value) {
+	c := *av
+	rv.Set(reflect.ValueOf(&c))
+}
+
+// decodes an integer stored as a string, see stringIntValueEncoder
+func stringIntDecoder(av *dynamodb.AttributeValue, rv reflect.Value) {
+	expectType(av, rv, "S", av.S != nil)
+	n, err := strconv.ParseInt(*av.S, 10, rv.Type().Bits())
+	if err != nil {
+		panic(InvalidNumberDecodeError{*av.S, rv.Type()})
+	}
+	rv.SetInt(n)
+}
+
+// decodes a time.Duration stored as a string, see
+// durationStringValueEncoder. An N of nanoseconds, as the field was
+// stored without the option, is read too.
+func durationStringDecoder(av *dynamodb.AttributeValue, rv reflect.Value) {
+	if av.N != nil {
+		intDecoder(av, rv)
+		return
+	}
+	expectType(av, rv, "S", av.S != nil)
+	d, err := time.ParseDuration(*av.S)
+	if err != nil {
+		panic(InvalidNumberDecodeError{*av.S, rv.Type()})
+	}
+	rv.SetInt(int64(d))
+}
+
+func floatDecoder(av *dynamodb.AttributeValue, rv reflect.Value) {
+	expectType(av, rv, "N", av.N != nil)
+	f, err := strconv.ParseFloat(*av.N, rv.Type().Bits())
+	if err != nil {
+		panic(InvalidNumberDecodeError{*av.N, rv.Type()})
+	}
+	rv.SetFloat(f)
+}
+func boolDecoder(av *dynamodb.AttributeValue, rv reflect.Value) {
+	expectType(av, rv, "BOOL", av.BOOL != nil)
+	rv.SetBool(*av.BOOL)
+}
+func bigIntDecoder(av *dynamodb.AttributeValue, rv reflect.Value) {
+	expectType(av, rv, "N", av.N != nil)
+	bi := rv.Addr().Interface().(*big.Int)
+	if _, ok := bi.SetString(*av.N, 10); !ok {
+		panic(InvalidNumberDecodeError{*av.N, rv.Type()})
+	}
+}
+
+// 128 bits of mantissa comfortably hold DynamoDB's 38 decimal digits
+func bigFloatDecoder(av *dynamodb.AttributeValue, rv reflect.Value) {
+	expectType(av, rv, "N", av.N != nil)
+	bf, _, err := big.ParseFloat(*av.N, 10, 128, big.ToNearestEven)
+	if err != nil {
+		panic(InvalidNumberDecodeError{*av.N, rv.Type()})
+	}
+	rv.Addr().Interface().(*big.Float).Set(bf)
+}
+func timeDecoder(av *dynamodb.AttributeValue, rv reflect.Value) {
+	expectType(av, rv, "S", av.S != nil)
+	t, err := time.Parse(time.RFC3339Nano, *av.S)
+	if err != nil {
+		panic(err)
+	}
+	rv.Set(reflect.ValueOf(t))
+}
+
+// decodes a net.IP from either its textual form or its bytes, see
+// ipValueEncoder
+func ipDecoder(av *dynamodb.AttributeValue, rv reflect.Value) {
+	if av.B != nil {
+		byteSliceDecoder(av, rv)
+		return
+	}
+	expectType(av, rv, "S or B", av.S != nil)
+	ip := net.ParseIP(*av.S)
+	if ip == nil {
+		panic(&net.ParseError{Type: "IP address", Text: *av.S})
+	}
+	rv.Set(reflect.ValueOf(ip))
+}
+
+func ipNetDecoder(av *dynamodb.AttributeValue, rv reflect.Value) {
+	expectType(av, rv, "S", av.S != nil)
+	_, ipn, err := net.ParseCIDR(*av.S)
+	if err != nil {
+		panic(err)
+	}
+	rv.Set(reflect.ValueOf(*ipn))
+}
+
+func urlDecoder(av *dynamodb.AttributeValue, rv reflect.Value) {
+	expectType(av, rv, "S", av.S != nil)
+	u, err := url.Parse(*av.S)
+	if err != nil {
+		panic(err)
+	}
+	rv.Set(reflect.ValueOf(*u))
+}
+
+// B attributes are copied, so the field doesn't share the response's
+// buffer, and SetBytes accepts named []byte types as well.
 func byteSliceDecoder(av *dynamodb.AttributeValue, rv reflect.Value) {
-	rv.Set(reflect.ValueOf(av.B))
+	expectType(av, rv, "B", av.B != nil)
+	b := make([]byte, len(av.B))
+	copy(b, av.B)
+	rv.SetBytes(b)
+}
+
+// a fixed size byte array must be given exactly as many bytes
+func byteArrayDecoder(av *dynamodb.AttributeValue, rv reflect.Value) {
+	expectType(av, rv, "B", av.B != nil)
+	if len(av.B) != rv.Len() {
+		panic(ByteLengthError{len(av.B), rv.Type()})
+	}
+	reflect.Copy(rv, reflect.ValueOf(av.B))
+}
+
+// Without a concrete type to decode into, the type is inferred from the
+// attribute in the same spirit as encoding/json:
+//   - S to string, B to []byte and BOOL to bool
+//   - N to int64 when it is integral, float64 otherwise
+//   - M to map[string]interface{} and L to []interface{}, recursively
+//   - SS, NS and BS to []interface{} of their inferred elements
+//   - NULL to nil
+//
+// Only the empty interface can be decoded into this way.
+func interfaceDecoder(av *dynamodb.AttributeValue, rv reflect.Value) {
+	if rv.NumMethod() != 0 {
+		panic(UnsupportedTypeDecoderError{rv.Type()})
+	}
+	if i := inferValue(av); i != nil {
+		rv.Set(reflect.ValueOf(i))
+	} else {
+		rv.Set(reflect.Zero(rv.Type()))
+	}
+}
+
+func inferValue(av *dynamodb.AttributeValue) interface{} {
+	switch {
+	case av.S != nil:
+		return *av.S
+	case av.N != nil:
+		return inferNumber(*av.N)
+	case av.BOOL != nil:
+		return *av.BOOL
+	case av.B != nil:
+		return av.B
+	case av.M != nil:
+		m := make(map[string]interface{}, len(av.M))
+		for k, e := range av.M {
+			m[k] = inferValue(e)
+		}
+		return m
+	case av.L != nil:
+		l := make([]interface{}, len(av.L))
+		for n, e := range av.L {
+			l[n] = inferValue(e)
+		}
+		return l
+	case av.SS != nil:
+		l := make([]interface{}, len(av.SS))
+		for n, s := range av.SS {
+			l[n] = *s
+		}
+		return l
+	case av.NS != nil:
+		l := make([]interface{}, len(av.NS))
+		for n, s := range av.NS {
+			l[n] = inferNumber(*s)
+		}
+		return l
+	case av.BS != nil:
+		l := make([]interface{}, len(av.BS))
+		for n, b := range av.BS {
+			l[n] = b
+		}
+		return l
+	}
+	return nil
+}
+
+func inferNumber(s string) interface{} {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		panic(InvalidNumberDecodeError{s, reflect.TypeOf(f)})
+	}
+	return f
 }
 
 type sliceDecoder struct {
@@ -104,20 +536,28 @@ type sliceDecoder struct {
 }
 
 func (sd *sliceDecoder) decode(av *dynamodb.AttributeValue, rv reflect.Value) {
-	avs := sd.explode(av)
+	expectType(av, rv, "L or set", av.L != nil || av.SS != nil || av.NS != nil || av.BS != nil)
+	avs := av.L
+	if avs != nil {
+		checkListElements(avs, rv.Type())
+	} else {
+		avs = sd.explode(av)
+	}
 	l := len(avs)
 	rv.Set(reflect.MakeSlice(rv.Type(), l, l))
 	for i, a := range avs {
-		sd.elemDecoder(a, rv.Index(i))
+		if !isNull(a) {
+			sd.elemDecoder(a, rv.Index(i))
+		}
 	}
 }
 
 // Creates a new slice decoder.
 // There are several aspects of the approach that constrain the solution
-//     - Arrays stored in this way are actually SETs (members will not repeat)
-//     - the *dynamodb.AttributeValue returned will be a SET of the undelying
-//       values of the array and has to be 'exploded' to reuse decode()
-//     - the type stored within the array should be consumable by decode()
+//   - Arrays stored in this way are actually SETs (members will not repeat)
+//   - the *dynamodb.AttributeValue returned will be a SET of the undelying
+//     values of the array and has to be 'exploded' to reuse decode()
+//   - the type stored within the array should be consumable by decode()
 //
 // The reuse of decode() keeps the code more consise, but it may be
 // simpler/faster to just re-implement a string/int decoder for arrays
@@ -126,19 +566,65 @@ func (sd *sliceDecoder) decode(av *dynamodb.AttributeValue, rv reflect.Value) {
 // and structs, or any possible composition of those elements.
 // IT WILL NOT CONSUME ARRAYS OF ARRAYS, and strictly speaking, this wouldn't
 // be partifularly useful in a DB - the data wouldn't be accessible / normalized.
-func newSliceDecoder(t reflect.Type) decoderFunc {
+func newSliceDecoder(dec *Decoder, t reflect.Type) decoderFunc {
 	et := t.Elem()
 	//this is a []byte return []byte decoder
 	if et.Kind() == reflect.Uint8 {
+		if t.Kind() == reflect.Array {
+			return byteArrayDecoder
+		}
 		return byteSliceDecoder
 	}
-	dec := sliceDecoder{newExploder(et), decoder(et)}
-	return dec.decode
+	sd := sliceDecoder{newExploder(et), dec.decoder(et)}
+	return sd.decode
+}
+
+// A list (from a field tagged `dynaGo:",list"`) keeps its order and
+// duplicates, and each element carries its own type. Elements must agree
+// with the kind of the slice's elements, NULL elements decode to zero.
+func checkListElements(avs []*dynamodb.AttributeValue, t reflect.Type) {
+	et := t.Elem()
+	for et.Kind() == reflect.Ptr {
+		et = et.Elem()
+	}
+	for n, av := range avs {
+		if isNull(av) {
+			continue
+		}
+		ok := true
+		switch et.Kind() {
+		case reflect.String:
+			ok = av.S != nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Float32, reflect.Float64:
+			ok = av.N != nil
+		case reflect.Bool:
+			ok = av.BOOL != nil
+		case reflect.Struct:
+			ok = !storedAsMap(et) || av.M != nil
+		case reflect.Map:
+			ok = av.M != nil || isSetMap(et) && av.SS != nil
+		}
+		if !ok {
+			panic(ListElementTypeError{n, t, av.String()})
+		}
+	}
 }
 
 type exploder func(av *dynamodb.AttributeValue) []*dynamodb.AttributeValue
 
+// the exploder of elements of type t which have no set type, and so are
+// only stored in lists
+func listOnlyExploder(t reflect.Type) exploder {
+	return func(av *dynamodb.AttributeValue) []*dynamodb.AttributeValue {
+		panic(UnsupportedArrayElementType{t})
+	}
+}
+
 func newExploder(t reflect.Type) exploder {
+	if t == timeType {
+		return newExploder(reflect.TypeOf(""))
+	}
 	switch t.Kind() {
 	case reflect.String:
 		return func(av *dynamodb.AttributeValue) []*dynamodb.AttributeValue {
@@ -149,16 +635,23 @@ func newExploder(t reflect.Type) exploder {
 			}
 			return arr
 		}
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Float32, reflect.Float64:
 		return func(av *dynamodb.AttributeValue) []*dynamodb.AttributeValue {
 			l := len(av.NS)
 			arr := make([]*dynamodb.AttributeValue, 0, l)
-			for _, s := range av.SS {
+			for _, s := range av.NS {
 				arr = append(arr, &dynamodb.AttributeValue{N: s})
 			}
 			return arr
 		}
+	case reflect.Bool, reflect.Map:
+		return listOnlyExploder(t)
 	case reflect.Struct:
+		// keyless structs are stored as a list of maps, never as a set
+		if storedAsMap(t) {
+			return listOnlyExploder(t)
+		}
 		i := getPartitionKey(t)
 		return newExploder(t.FieldByIndex(i).Type)
 	case reflect.Ptr:
@@ -168,14 +661,30 @@ func newExploder(t reflect.Type) exploder {
 	}
 }
 
-//if a struct is found, it's almost certainly the result of a pointer
-//dynaGo only Stores one layer of values, so we have to find the Hash key field,
-//compose the hierarchy above the field, and set that with the attribute value.
-func structDecoder(av *dynamodb.AttributeValue, rv reflect.Value) {
+// if a struct is found, it's almost certainly the result of a pointer
+// dynaGo only Stores one layer of values, so we have to find the Hash key field,
+// compose the hierarchy above the field, and set that with the attribute value.
+func (dec *Decoder) structDecoder(av *dynamodb.AttributeValue, rv reflect.Value) {
+	// keyless structs are stored whole, as an M of their fields, matched
+	// to them as the fields of an item are
+	if t := rv.Type(); !hasPartitionKey(t) {
+		expectType(av, rv, "M", av.M != nil)
+		fields := dec.fields(t)
+		m := av.M
+		if dec.fold {
+			m = foldAttributes(m, fields.list)
+		}
+		m = loosenNumbers(m, fields.list, dec.loose)
+		if dec.strict {
+			checkAttributes(m, t, fields)
+		}
+		dec.decodeFields(m, rv, fields.list)
+		return
+	}
 	i := getPartitionKey(rv.Type())
 	structCompose(rv, i)
 	fv := rv.FieldByIndex(i)
-	decoder(fv.Type())(av, fv)
+	dec.decoder(fv.Type())(av, fv)
 }
 
 // this function takes a value, and a field index and instantiates any
@@ -196,7 +705,7 @@ func structCompose(rv reflect.Value, i []int) {
 	}
 }
 
-//stores the underlying Elem decoder
+// stores the underlying Elem decoder
 type ptrDecoder struct {
 	elemDecoder decoderFunc
 }
@@ -207,9 +716,9 @@ func (pd *ptrDecoder) decode(av *dynamodb.AttributeValue, rv reflect.Value) {
 	}
 	pd.elemDecoder(av, rv.Elem())
 }
-func newPtrDecoder(t reflect.Type) decoderFunc {
-	dec := &ptrDecoder{decoder(t.Elem())}
-	return dec.decode
+func newPtrDecoder(dec *Decoder, t reflect.Type) decoderFunc {
+	pd := &ptrDecoder{dec.decoder(t.Elem())}
+	return pd.decode
 }
 
 type mapDecoder struct {
@@ -222,24 +731,212 @@ func (md *mapDecoder) decode(av *dynamodb.AttributeValue, rv reflect.Value) {
 	if t.Key().Kind() != reflect.String {
 		panic(UnsupportedTypeDecoderError{rv.Type()})
 	}
+	expectType(av, rv, "M", av.M != nil)
 	if rv.IsNil() {
 		rv.Set(reflect.MakeMap(t))
 	}
 	for k, av := range av.M {
-		kv := reflect.ValueOf(k)
+		kv := reflect.ValueOf(k).Convert(t.Key())
 		ev := reflect.New(elt).Elem()
-		md.elemDecoder(av, ev)
+		if !isNull(av) {
+			md.elemDecoder(av, ev)
+		}
 		rv.SetMapIndex(kv, ev)
 	}
 }
 
-func newMapDecoder(t reflect.Type) decoderFunc {
-	dec := &mapDecoder{decoder(t.Elem())}
-	return dec.decode
+func newMapDecoder(dec *Decoder, t reflect.Type) decoderFunc {
+	if t.Key().Kind() == reflect.String && isSetMap(t) {
+		return setMapDecoder
+	}
+	md := &mapDecoder{dec.decoder(t.Elem())}
+	return md.decode
+}
+
+// replaces the set map rv with the strings of the SS av
+func setMapDecoder(av *dynamodb.AttributeValue, rv reflect.Value) {
+	expectType(av, rv, "SS", av.SS != nil)
+	t := rv.Type()
+	m := reflect.MakeMapWithSize(t, len(av.SS))
+	in := reflect.New(t.Elem()).Elem()
+	for _, s := range av.SS {
+		m.SetMapIndex(reflect.ValueOf(*s).Convert(t.Key()), in)
+	}
+	rv.Set(m)
+}
+
+// replaces the map rv with one mapping each element of the SS or NS av
+// to true, see isBoolSetMap
+func boolSetMapDecoder(av *dynamodb.AttributeValue, rv reflect.Value) {
+	t := rv.Type()
+	num := t.Key().Kind() != reflect.String
+	if num {
+		expectType(av, rv, "NS", av.NS != nil)
+	} else {
+		expectType(av, rv, "SS", av.SS != nil)
+	}
+	m := reflect.MakeMapWithSize(t, len(av.SS)+len(av.NS))
+	yes := reflect.ValueOf(true).Convert(t.Elem())
+	for _, s := range av.SS {
+		m.SetMapIndex(reflect.ValueOf(*s).Convert(t.Key()), yes)
+	}
+	for _, s := range av.NS {
+		k := reflect.New(t.Key()).Elem()
+		intDecoder(&dynamodb.AttributeValue{N: s}, k)
+		m.SetMapIndex(k, yes)
+	}
+	rv.Set(m)
 }
 
 // --UTIL-- //
 
+// An item may hold an attribute explicitly set to NULL, which decodes
+// the same as an absent attribute: to the zero value (or nil pointer).
+//
+// Some SDKs and stream sources are looser about what they produce, so
+// the decoders tolerate
+//   - an AttributeValue with no type set at all, and
+//   - an N pointing to an empty string,
+//
+// both of which count as NULL. Only an attribute of another type than
+// the field expects (an S for an int field, say) is an error, reported
+// as an AttributeTypeError.
+func isNull(av *dynamodb.AttributeValue) bool {
+	return av == nil || (av.NULL != nil && *av.NULL) ||
+		attributeType(av) == "" || (av.N != nil && *av.N == "")
+}
+
+// the name of the type held by av, "" when none is set
+func attributeType(av *dynamodb.AttributeValue) string {
+	switch {
+	case av.S != nil:
+		return "S"
+	case av.N != nil:
+		return "N"
+	case av.B != nil:
+		return "B"
+	case av.BOOL != nil:
+		return "BOOL"
+	case av.NULL != nil:
+		return "NULL"
+	case av.M != nil:
+		return "M"
+	case av.L != nil:
+		return "L"
+	case av.SS != nil:
+		return "SS"
+	case av.NS != nil:
+		return "NS"
+	case av.BS != nil:
+		return "BS"
+	}
+	return ""
+}
+
+// panics with an AttributeTypeError unless ok, the attribute holding
+// the type want which rv is decoded from
+func expectType(av *dynamodb.AttributeValue, rv reflect.Value, want string, ok bool) {
+	if !ok {
+		panic(AttributeTypeError{want, attributeType(av), rv.Type()})
+	}
+}
+
+// panics with an UnknownAttributeError for the first attribute of m (in
+// sorted order, so the error is stable) which no field is named for.
+func checkAttributes(m map[string]*dynamodb.AttributeValue, t reflect.Type, fields *structFields) {
+	unknown := make([]string, 0)
+	for an := range m {
+		if _, ok := fields.byName[an]; !ok {
+			unknown = append(unknown, an)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		panic(UnknownAttributeError{t, unknown[0]})
+	}
+}
+
+// renames the attributes of m matching the name of a field but for case
+// to the name of that field, leaving attributes matching exactly as they
+// are. m itself is not modified.
+func foldAttributes(m map[string]*dynamodb.AttributeValue, fields []field) map[string]*dynamodb.AttributeValue {
+	folded := make(map[string]string, len(fields))
+	for _, f := range fields {
+		folded[strings.ToLower(f.name)] = f.name
+	}
+	ans := make([]string, 0, len(m))
+	for an := range m {
+		ans = append(ans, an)
+	}
+	sort.Strings(ans)
+	fm := make(map[string]*dynamodb.AttributeValue, len(m))
+	for _, an := range ans {
+		fn, ok := folded[strings.ToLower(an)]
+		if _, exact := m[fn]; !ok || exact {
+			fm[an] = m[an]
+		} else if _, taken := fm[fn]; !taken {
+			fm[fn] = m[an]
+		}
+	}
+	return fm
+}
+
+// replaces the S attributes of m holding numbers for numeric fields with
+// an N of the same number, and the N attributes of string fields with an
+// S of its text, when lenient. String fields with the option number have
+// their N replaced either way. m itself is not modified.
+func loosenNumbers(m map[string]*dynamodb.AttributeValue, fields []field, lenient bool) map[string]*dynamodb.AttributeValue {
+	var lm map[string]*dynamodb.AttributeValue
+	for _, f := range fields {
+		if !lenient && !f.num {
+			continue
+		}
+		av, ok := m[f.name]
+		if !ok || f.str || f.dur {
+			continue
+		}
+		t := f.typ
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		var la *dynamodb.AttributeValue
+		switch t.Kind() {
+		case reflect.String:
+			if av.N != nil {
+				la = &dynamodb.AttributeValue{S: av.N}
+			}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if av.S == nil {
+				break
+			}
+			if _, err := strconv.ParseInt(*av.S, 10, t.Bits()); err == nil {
+				la = &dynamodb.AttributeValue{N: av.S}
+			}
+		case reflect.Float32, reflect.Float64:
+			if av.S == nil {
+				break
+			}
+			if _, err := strconv.ParseFloat(*av.S, t.Bits()); err == nil {
+				la = &dynamodb.AttributeValue{N: av.S}
+			}
+		}
+		if la == nil {
+			continue
+		}
+		if lm == nil {
+			lm = make(map[string]*dynamodb.AttributeValue, len(m))
+			for an, av := range m {
+				lm[an] = av
+			}
+		}
+		lm[f.name] = la
+	}
+	if lm == nil {
+		return m
+	}
+	return lm
+}
+
 // The name stored in this struct helps map from the
 // DB attributeName (or column) to the struct field name.
 // The values cached here to avoid noisey functions
@@ -248,21 +945,99 @@ type field struct {
 
 	index []int
 	typ   reflect.Type
+	// the value of a default= option, if any
+	def *string
+	// an integer stored as a string
+	str bool
+	// stored as cipher text
+	encrypt bool
+	// a time.Duration stored as a string
+	dur bool
+	// the separator of the shard suffix of a sharded key
+	shardSep string
+	// a time.Time stored as the epoch seconds of a TTL
+	ttl bool
+	// a map to bool stored as the set of its true keys
+	boolSet bool
+	// a string decoded from an N as well as an S
+	num bool
 }
 
-func newField(sf reflect.StructField) field {
-	return field{
-		name:  getAttrName(sf),
+func newField(sf reflect.StructField, namer func(string) string) field {
+	f := field{
+		name:  attrName(sf, namer),
 		index: sf.Index,
 		typ:   sf.Type,
 	}
+	_, o := parseTag(sf.Tag.Get("dynaGo"))
+	if ds := o.Values("default"); len(ds) > 0 {
+		f.def = &ds[0]
+	}
+	_, f.str = stringInt(sf)
+	f.encrypt = isEncrypted(sf)
+	f.dur = isDurationString(sf)
+	if _, sep, ok := keyShards(sf); ok {
+		f.shardSep = sep
+	}
+	f.boolSet = isBoolSetMap(sf)
+	f.num = o.Contains("number") && (sf.Type.Kind() == reflect.String || sf.Type.Kind() == reflect.Ptr && sf.Type.Elem().Kind() == reflect.String)
+	f.ttl = isTTL(sf) && (sf.Type == timeType || sf.Type.Kind() == reflect.Ptr && sf.Type.Elem() == timeType)
+	return f
+}
+
+// The attribute decoded in place of an absent one, for a field with the
+// option default=value. value is parsed according to the kind of the
+// field (or of the type it points to): strings take it as is, and ints,
+// floats and bools must parse as such. Anything else panics with a
+// DefaultValueError.
+func (f field) defaultAttribute() *dynamodb.AttributeValue {
+	t := f.typ
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	d := *f.def
+	var err error
+	switch t.Kind() {
+	case reflect.String:
+		return &dynamodb.AttributeValue{S: &d}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if _, err = strconv.ParseInt(d, 10, t.Bits()); err == nil {
+			return &dynamodb.AttributeValue{N: &d}
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, err = strconv.ParseFloat(d, t.Bits()); err == nil {
+			return &dynamodb.AttributeValue{N: &d}
+		}
+	case reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(d); err == nil {
+			return &dynamodb.AttributeValue{BOOL: &b}
+		}
+	}
+	panic(DefaultValueError{d, f.typ})
+}
+
+// structFields are the fields of a struct type as they are decoded, and
+// the index path (through any embedded structs) of each by attribute name.
+type structFields struct {
+	list   []field
+	byName map[string][]int
+}
+
+func newStructFields(t reflect.Type, namer func(string) string) *structFields {
+	fs := &structFields{list: typeFields(t, namer)}
+	fs.byName = make(map[string][]int, len(fs.list))
+	for _, f := range fs.list {
+		fs.byName[f.name] = f.index
+	}
+	return fs
 }
 
-func typeFields(t reflect.Type) (fields []field) {
+func typeFields(t reflect.Type, namer func(string) string) (fields []field) {
 	fields = make([]field, 0)
 
-	for i := 0; i < t.NumField(); i++ {
-		sf := newField(t.Field(i))
+	for _, f := range attrFields(t) {
+		sf := newField(f, namer)
 		fields = append(fields, sf)
 	}
 	return
@@ -5,7 +5,12 @@
 package dynaGo
 
 import (
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
 	"reflect"
+	"sort"
 	"testing"
 	"time"
 
@@ -13,6 +18,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
 )
 
 // Don't think this test will ever fail unless someone panics.
@@ -89,3 +95,1000 @@ func exercise(t *testing.T, svc *dynamodb.DynamoDB, i interface{}) interface{} {
 	}
 	return items.Interface()
 }
+
+type Doc struct {
+	Id    string `dynaGo:",HASH"`
+	Attrs map[string]interface{}
+	Any   interface{}
+}
+
+func TestDecodeInterface(t *testing.T) {
+	item := map[string]*dynamodb.AttributeValue{
+		"Id": {S: aws.String("doc")},
+		"Attrs": {M: map[string]*dynamodb.AttributeValue{
+			"name":  {S: aws.String("bob")},
+			"age":   {N: aws.String("42")},
+			"ratio": {N: aws.String("0.5")},
+			"admin": {BOOL: aws.Bool(true)},
+			"gone":  {NULL: aws.Bool(true)},
+			"address": {M: map[string]*dynamodb.AttributeValue{
+				"zip": {S: aws.String("97201")},
+			}},
+			"tags": {L: []*dynamodb.AttributeValue{
+				{S: aws.String("a")},
+				{N: aws.String("1")},
+				{M: map[string]*dynamodb.AttributeValue{"x": {BOOL: aws.Bool(false)}}},
+			}},
+			"peers": {SS: []*string{aws.String("alice")}},
+		}},
+		"Any": {L: []*dynamodb.AttributeValue{{N: aws.String("-7")}}},
+	}
+	want := Doc{
+		Id: "doc",
+		Attrs: map[string]interface{}{
+			"name":    "bob",
+			"age":     int64(42),
+			"ratio":   0.5,
+			"admin":   true,
+			"gone":    nil,
+			"address": map[string]interface{}{"zip": "97201"},
+			"tags":    []interface{}{"a", int64(1), map[string]interface{}{"x": false}},
+			"peers":   []interface{}{"alice"},
+		},
+		Any: []interface{}{int64(-7)},
+	}
+	var d Doc
+	if err := Unmarshal(item, &d); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("failed: decoded\n\t%#v\nwant\n\t%#v", d, want)
+	}
+}
+
+type Schedule struct {
+	Id    string `dynaGo:",HASH"`
+	At    time.Time
+	Times []time.Time
+}
+
+func TestTimeRoundTrip(t *testing.T) {
+	at := time.Date(2016, 8, 1, 12, 30, 0, 0, time.FixedZone("PDT", -7*3600))
+	s := Schedule{
+		Id: "standup",
+		At: at,
+		Times: []time.Time{
+			at.Add(48 * time.Hour),
+			at.Add(24 * time.Hour),
+			at.Add(1500 * time.Millisecond),
+		},
+	}
+	item := Marshal(s).Item
+	if v := *item["At"].S; v != "2016-08-01T19:30:00Z" {
+		t.Errorf("failed: At encoded as %s", v)
+	}
+	if len(item["Times"].SS) != 3 || *item["Times"].SS[2] != "2016-08-01T19:30:01.5Z" {
+		t.Errorf("failed: Times encoded as %v", item["Times"])
+	}
+	var out Schedule
+	if err := Unmarshal(item, &out); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if !out.At.Equal(s.At) {
+		t.Errorf("failed: At decoded as %s", out.At)
+	}
+	// string sets are unordered
+	sort.Slice(out.Times, func(i, j int) bool { return out.Times[i].Before(out.Times[j]) })
+	sort.Slice(s.Times, func(i, j int) bool { return s.Times[i].Before(s.Times[j]) })
+	if len(out.Times) != 3 {
+		t.Fatalf("failed: decoded %d Times", len(out.Times))
+	}
+	for n := range s.Times {
+		if !out.Times[n].Equal(s.Times[n]) {
+			t.Errorf("failed: Times[%d] decoded as %s", n, out.Times[n])
+		}
+	}
+	if _, ok := Marshal(Schedule{Id: "never"}).Item["At"]; ok {
+		t.Error("failed: zero time was encoded")
+	}
+}
+
+type Profile struct {
+	Id      string `dynaGo:",HASH"`
+	Nick    *string
+	Age     int
+	Email   string
+	Peers   []string
+	Extra   map[string]string
+	Manager *Usr
+	Since   time.Time
+}
+
+func TestDecodeSparse(t *testing.T) {
+	item := map[string]*dynamodb.AttributeValue{
+		"Id":    {S: aws.String("p1")},
+		"Nick":  {NULL: aws.Bool(true)},
+		"Extra": {M: map[string]*dynamodb.AttributeValue{"gone": {NULL: aws.Bool(true)}}},
+	}
+	var p Profile
+	if err := Unmarshal(item, &p); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	want := Profile{Id: "p1", Extra: map[string]string{"gone": ""}}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("failed: decoded %+v, want %+v", p, want)
+	}
+	// empty values dropped by Marshal come back as zero values
+	var rt Profile
+	if err := Unmarshal(Marshal(Profile{Id: "p2"}).Item, &rt); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if !reflect.DeepEqual(rt, Profile{Id: "p2"}) {
+		t.Errorf("failed: round trip of empty profile %+v", rt)
+	}
+}
+
+type Playlist struct {
+	Id     string   `dynaGo:",HASH"`
+	Tracks []string `dynaGo:",list"`
+	Plays  []int    `dynaGo:",list"`
+	Skips  []int
+}
+
+func TestListRoundTrip(t *testing.T) {
+	p := Playlist{
+		Id:     "mix",
+		Tracks: []string{"b", "a", "b", "", "c"},
+		Plays:  []int{3, 1, 3},
+		Skips:  []int{4, 2},
+	}
+	item := Marshal(p).Item
+	if l := item["Tracks"].L; len(l) != 5 || *l[0].S != "b" || *l[2].S != "b" || !*l[3].NULL {
+		t.Errorf("failed: Tracks encoded as %v", item["Tracks"])
+	}
+	if len(item["Skips"].NS) != 2 {
+		t.Errorf("failed: Skips encoded as %v", item["Skips"])
+	}
+	var out Playlist
+	if err := Unmarshal(item, &out); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if !reflect.DeepEqual(out, p) {
+		t.Errorf("failed: decoded %+v, want %+v", out, p)
+	}
+}
+
+func TestListElementMismatch(t *testing.T) {
+	item := map[string]*dynamodb.AttributeValue{
+		"Id":    {S: aws.String("mix")},
+		"Plays": {L: []*dynamodb.AttributeValue{{N: aws.String("1")}, {S: aws.String("two")}}},
+	}
+	var out Playlist
+	err := Unmarshal(item, &out)
+	if lee, ok := err.(ListElementTypeError); !ok || lee.Index != 1 {
+		t.Errorf("failed: expected ListElementTypeError for element 1, got %v", err)
+	}
+}
+
+type Digest []byte
+
+type Blob struct {
+	Id   string `dynaGo:",HASH"`
+	Data []byte
+	Sum  Digest
+	UUID [16]byte
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	b := Blob{
+		Id:   "b1",
+		Data: []byte{0xde, 0xad, 0xbe, 0xef},
+		Sum:  Digest{0x01, 0x02},
+		UUID: [16]byte{0: 0x12, 15: 0x34},
+	}
+	item := Marshal(b).Item
+	if len(item["UUID"].B) != 16 || item["UUID"].B[15] != 0x34 {
+		t.Errorf("failed: UUID encoded as %v", item["UUID"])
+	}
+	var out Blob
+	if err := Unmarshal(item, &out); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if !reflect.DeepEqual(out, b) {
+		t.Errorf("failed: decoded %+v, want %+v", out, b)
+	}
+	// the decoded slice must not alias the item
+	item["Data"].B[0] = 0
+	if out.Data[0] != 0xde {
+		t.Error("failed: decoded []byte shares the attribute's buffer")
+	}
+	item["UUID"].B = item["UUID"].B[:15]
+	if err := Unmarshal(item, &out); err == nil {
+		t.Error("failed: expected ByteLengthError for 15 bytes into [16]byte")
+	}
+}
+
+type Money struct {
+	Amount   int64
+	Currency string `dynaGo:"cur"`
+}
+
+type Invoice struct {
+	Id    string `dynaGo:",HASH"`
+	Total Money
+	Paid  *Money
+}
+
+func TestKeylessNestedStruct(t *testing.T) {
+	in := Invoice{Id: "i1", Total: Money{1250, "EUR"}, Paid: &Money{Amount: 500, Currency: "EUR"}}
+	item := Marshal(in).Item
+	total := item["Total"]
+	if total == nil || total.M == nil || *total.M["Amount"].N != "1250" || *total.M["cur"].S != "EUR" {
+		t.Fatalf("failed: Total encoded as %v", total)
+	}
+	var out Invoice
+	if err := Unmarshal(item, &out); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if out.Total != in.Total || out.Paid == nil || *out.Paid != *in.Paid {
+		t.Errorf("failed: decoded %+v, want %+v", out, in)
+	}
+}
+
+type Auditable struct {
+	CreatedAt int64
+	UpdatedAt int64 `dynaGo:"updated"`
+}
+
+type Note struct {
+	Id string `dynaGo:",HASH"`
+	Auditable
+	Text string
+}
+
+type Draft struct {
+	Id string `dynaGo:",HASH"`
+	*Auditable
+}
+
+func TestEmbeddedStruct(t *testing.T) {
+	n := Note{Id: "n1", Auditable: Auditable{100, 200}, Text: "hi"}
+	item := Marshal(n).Item
+	if *item["CreatedAt"].N != "100" || *item["updated"].N != "200" || item["Auditable"] != nil {
+		t.Fatalf("failed: embedded fields not promoted %v", item)
+	}
+	var out Note
+	if err := Unmarshal(item, &out); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if out != n {
+		t.Errorf("failed: decoded %+v, want %+v", out, n)
+	}
+
+	// an unset embedded pointer writes nothing, and is allocated on decode
+	// only when its attributes are present
+	if item := Marshal(Draft{Id: "d1"}).Item; len(item) != 1 {
+		t.Errorf("failed: nil embedded pointer encoded as %v", item)
+	}
+	var d Draft
+	if err := Unmarshal(Marshal(Draft{Id: "d1"}).Item, &d); err != nil || d.Auditable != nil {
+		t.Errorf("failed: allocated embedded pointer %v %v", d.Auditable, err)
+	}
+	if err := Unmarshal(item, &d); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if d.Auditable == nil || *d.Auditable != n.Auditable {
+		t.Errorf("failed: decoded embedded pointer %+v", d.Auditable)
+	}
+}
+
+type Ticket struct {
+	Id       string `dynaGo:",HASH"`
+	Status   string `dynaGo:",default=open"`
+	Priority int    `dynaGo:",default=3"`
+	Owner    *string
+}
+
+type BadDefault struct {
+	Id    string `dynaGo:",HASH"`
+	Count int    `dynaGo:",default=many"`
+}
+
+func TestDecodeDefault(t *testing.T) {
+	// ints are always written, the item of an older writer lacks Priority
+	item := Marshal(Ticket{Id: "t1"}).Item
+	delete(item, "Priority")
+	var tk Ticket
+	if err := Unmarshal(item, &tk); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if tk.Status != "open" || tk.Priority != 3 || tk.Owner != nil {
+		t.Errorf("failed: defaults not applied %+v", tk)
+	}
+	tk = Ticket{}
+	if err := Unmarshal(Marshal(Ticket{Id: "t1", Status: "closed"}).Item, &tk); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if tk.Status != "closed" || tk.Priority != 0 {
+		t.Errorf("failed: defaults overrode stored values %+v", tk)
+	}
+	item = Marshal(BadDefault{Id: "b1"}).Item
+	delete(item, "Count")
+	err := Unmarshal(item, &BadDefault{})
+	if _, ok := err.(DefaultValueError); !ok {
+		t.Errorf("failed: expected DefaultValueError, got %v", err)
+	}
+}
+
+func TestDecodeTolerance(t *testing.T) {
+	id, six := "t1", "6"
+	item := map[string]*dynamodb.AttributeValue{
+		"Id": {S: &id},
+		// no type set at all, and an empty number, both count as absent
+		"Status":   {},
+		"Priority": {N: new(string)},
+		"Owner":    {S: nil},
+	}
+	tk := Ticket{Priority: 9}
+	if err := Unmarshal(item, &tk); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if tk.Status != "" || tk.Priority != 9 || tk.Owner != nil {
+		t.Errorf("failed: typeless attributes decoded %+v", tk)
+	}
+	// a number where a string is expected is clearly wrong
+	item["Status"] = &dynamodb.AttributeValue{N: &six}
+	err := Unmarshal(item, &tk)
+	if ate, ok := err.(AttributeTypeError); !ok || ate.Want != "S" || ate.Found != "N" {
+		t.Errorf("failed: expected AttributeTypeError, got %v", err)
+	}
+}
+
+func TestEmptyList(t *testing.T) {
+	p := Playlist{Id: "p1", Tracks: []string{}}
+	item := Marshal(p).Item
+	if av, ok := item["Tracks"]; !ok || av.L == nil || len(av.L) != 0 {
+		t.Errorf("failed: empty list encoded as %v", av)
+	}
+	if _, ok := item["Plays"]; ok {
+		t.Errorf("failed: nil list encoded as %v", item["Plays"])
+	}
+	var out Playlist
+	if err := Unmarshal(item, &out); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if out.Tracks == nil || len(out.Tracks) != 0 || out.Plays != nil {
+		t.Errorf("failed: decoded %#v", out)
+	}
+}
+
+func TestListNilEmptyPopulated(t *testing.T) {
+	for _, c := range []struct {
+		tracks []string
+		want   string
+	}{
+		{nil, ""},
+		{[]string{}, "[]"},
+		{[]string{"a", "b"}, "[a b]"},
+	} {
+		p := Playlist{Id: "p1", Tracks: c.tracks}
+		for op, item := range map[string]map[string]*dynamodb.AttributeValue{
+			"put":    Marshal(p).Item,
+			"update": updateValues(t, p),
+		} {
+			av, ok := item["Tracks"]
+			got := ""
+			if ok {
+				ss := make([]string, len(av.L))
+				for n, e := range av.L {
+					ss[n] = *e.S
+				}
+				got = fmt.Sprint(ss)
+				if av.L == nil {
+					got = "non-list " + av.String()
+				}
+			}
+			if got != c.want {
+				t.Errorf("failed: %s of %#v wrote %q, want %q", op, c.tracks, got, c.want)
+			}
+		}
+		var out Playlist
+		if err := Unmarshal(Marshal(p).Item, &out); err != nil {
+			t.Fatalf("failed: %s", err)
+		}
+		if (out.Tracks == nil) != (c.tracks == nil) || len(out.Tracks) != len(c.tracks) {
+			t.Errorf("failed: %#v decoded as %#v", c.tracks, out.Tracks)
+		}
+	}
+}
+
+// the attributes an Update of i sets, by attribute name
+func updateValues(t *testing.T, i interface{}) map[string]*dynamodb.AttributeValue {
+	in, err := Update(i).Input()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	m := make(map[string]*dynamodb.AttributeValue)
+	for alias, an := range in.ExpressionAttributeNames {
+		m[*an] = in.ExpressionAttributeValues[":v"+alias[2:]]
+	}
+	return m
+}
+
+type Handler struct {
+	Id       string `dynaGo:",HASH"`
+	Callback func()
+	Events   chan string
+}
+
+func TestDecodeFuncField(t *testing.T) {
+	id := "h1"
+	item := map[string]*dynamodb.AttributeValue{"Id": {S: &id}}
+	var h Handler
+	if err := Unmarshal(item, &h); err != nil || h.Id != "h1" {
+		t.Errorf("failed: fields without attributes weren't skipped %v", err)
+	}
+	item["Callback"] = &dynamodb.AttributeValue{S: &id}
+	err := Unmarshal(item, &h)
+	if fe, ok := err.(FieldKindDecodeError); !ok || fe.FieldName != "Callback" || fe.Kind != reflect.Func {
+		t.Errorf("failed: expected FieldKindDecodeError, got %v", err)
+	}
+}
+
+func TestUnmarshalValue(t *testing.T) {
+	s, n, yes := "bob", "42.5", true
+	var str string
+	if err := UnmarshalValue(&dynamodb.AttributeValue{S: &s}, &str); err != nil || str != "bob" {
+		t.Errorf("failed: S %q %v", str, err)
+	}
+	var f float64
+	if err := UnmarshalValue(&dynamodb.AttributeValue{N: &n}, &f); err != nil || f != 42.5 {
+		t.Errorf("failed: N %v %v", f, err)
+	}
+	var b bool
+	if err := UnmarshalValue(&dynamodb.AttributeValue{BOOL: &yes}, &b); err != nil || !b {
+		t.Errorf("failed: BOOL %v %v", b, err)
+	}
+	var bs []byte
+	if err := UnmarshalValue(&dynamodb.AttributeValue{B: []byte{1, 2}}, &bs); err != nil || len(bs) != 2 {
+		t.Errorf("failed: B %v %v", bs, err)
+	}
+	var ss []string
+	if err := UnmarshalValue(&dynamodb.AttributeValue{SS: []*string{&s}}, &ss); err != nil || ss[0] != "bob" {
+		t.Errorf("failed: SS %v %v", ss, err)
+	}
+	var ns []int
+	six := "6"
+	if err := UnmarshalValue(&dynamodb.AttributeValue{NS: []*string{&six}}, &ns); err != nil || ns[0] != 6 {
+		t.Errorf("failed: NS %v %v", ns, err)
+	}
+	var l []string
+	if err := UnmarshalValue(&dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{{S: &s}, {S: &s}}}, &l); err != nil ||
+		len(l) != 2 || l[1] != "bob" {
+		t.Errorf("failed: L %v %v", l, err)
+	}
+	var m map[string]string
+	if err := UnmarshalValue(&dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{"k": {S: &s}}}, &m); err != nil ||
+		m["k"] != "bob" {
+		t.Errorf("failed: M %v %v", m, err)
+	}
+	str = "set"
+	if err := UnmarshalValue(&dynamodb.AttributeValue{NULL: &yes}, &str); err != nil || str != "" {
+		t.Errorf("failed: NULL %q %v", str, err)
+	}
+
+	if err := UnmarshalValue(&dynamodb.AttributeValue{S: &s}, &f); err == nil {
+		t.Error("failed: expected error decoding S into float64")
+	}
+	if err := UnmarshalValue(&dynamodb.AttributeValue{S: &s}, &m); err == nil {
+		t.Error("failed: expected error decoding S into a map")
+	}
+	if err := UnmarshalValue(&dynamodb.AttributeValue{S: &s}, str); err == nil {
+		t.Error("failed: expected error decoding into a non-pointer")
+	}
+}
+
+func TestUnmarshalStreamImage(t *testing.T) {
+	type Basket struct {
+		Id     string `dynaGo:",HASH"`
+		Totals map[string]Money
+		Items  []string `dynaGo:",list"`
+		Tags   []string
+	}
+	b := Basket{
+		Id:     "b1",
+		Totals: map[string]Money{"eu": {Amount: 5, Currency: "EUR"}},
+		Items:  []string{"tea", "tea"},
+		Tags:   []string{"gift"},
+	}
+	r := &dynamodbstreams.StreamRecord{NewImage: Marshal(b).Item}
+	var out Basket
+	if err := Unmarshal(r.NewImage, &out); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if !reflect.DeepEqual(out, b) {
+		t.Errorf("failed: decoded %+v, want %+v", out, b)
+	}
+}
+
+func TestRawAttributeMap(t *testing.T) {
+	type Event struct {
+		Id      string `dynaGo:",HASH"`
+		Payload map[string]*dynamodb.AttributeValue
+		Extra   map[string]*dynamodb.AttributeValue
+	}
+	kind, yes := "click", true
+	e := Event{Id: "e1", Payload: map[string]*dynamodb.AttributeValue{
+		"kind":   {S: &kind},
+		"nested": {M: map[string]*dynamodb.AttributeValue{"ok": {BOOL: &yes}}},
+	}}
+	item := Marshal(e).Item
+	if av := item["Payload"]; av == nil || av.M == nil || *av.M["kind"].S != "click" || !*av.M["nested"].M["ok"].BOOL {
+		t.Errorf("failed: raw map encoded as %v", av)
+	}
+	if _, ok := item["Extra"]; ok {
+		t.Errorf("failed: nil raw map encoded as %v", item["Extra"])
+	}
+	var out Event
+	if err := Unmarshal(item, &out); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if !reflect.DeepEqual(out, e) {
+		t.Errorf("failed: decoded %+v, want %+v", out, e)
+	}
+	item["Payload"] = &dynamodb.AttributeValue{S: &kind}
+	if err := Unmarshal(item, &out); err == nil {
+		t.Error("failed: expected error decoding S into a raw map")
+	}
+}
+
+func TestMapRoundTrip(t *testing.T) {
+	type Dims struct {
+		W, H int
+	}
+	type Label string
+	type Catalog struct {
+		Id     string `dynaGo:",HASH"`
+		Names  map[string]string
+		Sizes  map[string][]int
+		Dims   map[string]Dims
+		Labels map[Label]float64
+	}
+	c := Catalog{
+		Id:     "c1",
+		Names:  map[string]string{"en": "chair", "fr": "chaise"},
+		Sizes:  map[string][]int{"small": {1, 2}, "large": {8}},
+		Dims:   map[string]Dims{"box": {2, 3}},
+		Labels: map[Label]float64{"tax": 0.2},
+	}
+	item := Marshal(c).Item
+	if n := item["Sizes"].M["small"].NS; len(n) != 2 {
+		t.Errorf("failed: Sizes encoded as %v", item["Sizes"])
+	}
+	if w := item["Dims"].M["box"].M["W"].N; w == nil || *w != "2" {
+		t.Errorf("failed: Dims encoded as %v", item["Dims"])
+	}
+	var got Catalog
+	if err := Unmarshal(item, &got); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	sort.Ints(got.Sizes["small"])
+	if !reflect.DeepEqual(got, c) {
+		t.Errorf("failed: round trip %+v", got)
+	}
+}
+
+func TestPointerScalarFields(t *testing.T) {
+	type Reading struct {
+		Id    string `dynaGo:",HASH"`
+		Count *int
+		Label *string
+		Value *float64
+	}
+	null := true
+	for _, tc := range []struct {
+		name string
+		item map[string]*dynamodb.AttributeValue
+		want bool
+	}{
+		{"present", map[string]*dynamodb.AttributeValue{
+			"Count": {N: aws.String("3")}, "Label": {S: aws.String("x")}, "Value": {N: aws.String("1.5")},
+		}, true},
+		{"absent", map[string]*dynamodb.AttributeValue{}, false},
+		{"NULL", map[string]*dynamodb.AttributeValue{
+			"Count": {NULL: &null}, "Label": {NULL: &null}, "Value": {NULL: &null},
+		}, false},
+	} {
+		var r Reading
+		if err := Unmarshal(tc.item, &r); err != nil {
+			t.Fatalf("failed: %s: %s", tc.name, err)
+		}
+		if !tc.want {
+			if r.Count != nil || r.Label != nil || r.Value != nil {
+				t.Errorf("failed: %s set %+v", tc.name, r)
+			}
+			continue
+		}
+		if r.Count == nil || *r.Count != 3 || r.Label == nil || *r.Label != "x" || r.Value == nil || *r.Value != 1.5 {
+			t.Errorf("failed: %s decoded %+v", tc.name, r)
+		}
+	}
+}
+
+func TestSetMapRoundTrip(t *testing.T) {
+	type Post struct {
+		Id   string `dynaGo:",HASH"`
+		Tags map[string]struct{}
+	}
+	p := Post{Id: "p1", Tags: map[string]struct{}{"go": {}, "aws": {}}}
+	item := Marshal(p).Item
+	if ss := item["Tags"].SS; len(ss) != 2 || *ss[0] != "aws" || *ss[1] != "go" {
+		t.Errorf("failed: Tags encoded as %v", item["Tags"])
+	}
+	var got Post
+	if err := Unmarshal(item, &got); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if !reflect.DeepEqual(got, p) {
+		t.Errorf("failed: round trip %+v", got)
+	}
+	for _, tags := range []map[string]struct{}{nil, {}} {
+		if _, ok := Marshal(Post{Id: "p2", Tags: tags}).Item["Tags"]; ok {
+			t.Errorf("failed: empty set %v written", tags)
+		}
+	}
+}
+
+func TestDurationRoundTrip(t *testing.T) {
+	type Job struct {
+		Id      string `dynaGo:",HASH"`
+		Timeout time.Duration
+		Every   time.Duration  `dynaGo:",string"`
+		Grace   *time.Duration `dynaGo:",string"`
+	}
+	grace := 90 * time.Second
+	j := Job{Id: "j1", Timeout: 1500 * time.Millisecond, Every: 90 * time.Minute, Grace: &grace}
+	item := Marshal(j).Item
+	if n := item["Timeout"].N; n == nil || *n != "1500000000" {
+		t.Errorf("failed: Timeout encoded as %v", item["Timeout"])
+	}
+	if s := item["Every"].S; s == nil || *s != "1h30m0s" {
+		t.Errorf("failed: Every encoded as %v", item["Every"])
+	}
+	if s := item["Grace"].S; s == nil || *s != "1m30s" {
+		t.Errorf("failed: Grace encoded as %v", item["Grace"])
+	}
+	var got Job
+	if err := Unmarshal(item, &got); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if !reflect.DeepEqual(got, j) {
+		t.Errorf("failed: round trip %+v", got)
+	}
+
+	// a string field still reads the nanoseconds it was stored as before
+	item["Every"] = &dynamodb.AttributeValue{N: aws.String("60000000000")}
+	if err := Unmarshal(item, &got); err != nil || got.Every != time.Minute {
+		t.Errorf("failed: Every decoded from N as %s %v", got.Every, err)
+	}
+	item["Every"] = &dynamodb.AttributeValue{S: aws.String("soon")}
+	if err := Unmarshal(item, &got); err == nil {
+		t.Error("failed: expected error decoding an invalid duration")
+	}
+}
+
+func TestNetAndURLRoundTrip(t *testing.T) {
+	type Host struct {
+		Id      string `dynaGo:",HASH"`
+		Addr    net.IP
+		Raw     net.IP `dynaGo:",type=B"`
+		Subnet  net.IPNet
+		Home    url.URL
+		Mirror  *url.URL
+		Missing net.IP
+	}
+	_, subnet, _ := net.ParseCIDR("10.1.0.0/16")
+	home, _ := url.Parse("https://example.com/a?b=c")
+	h := Host{
+		Id:     "h1",
+		Addr:   net.ParseIP("10.1.2.3"),
+		Raw:    net.ParseIP("2001:db8::1"),
+		Subnet: *subnet,
+		Home:   *home,
+		Mirror: home,
+	}
+	item := Marshal(h).Item
+	if s := item["Addr"].S; s == nil || *s != "10.1.2.3" {
+		t.Errorf("failed: Addr encoded as %v", item["Addr"])
+	}
+	if b := item["Raw"].B; len(b) != net.IPv6len {
+		t.Errorf("failed: Raw encoded as %v", item["Raw"])
+	}
+	if s := item["Subnet"].S; s == nil || *s != "10.1.0.0/16" {
+		t.Errorf("failed: Subnet encoded as %v", item["Subnet"])
+	}
+	if s := item["Home"].S; s == nil || *s != "https://example.com/a?b=c" {
+		t.Errorf("failed: Home encoded as %v", item["Home"])
+	}
+	if _, ok := item["Missing"]; ok {
+		t.Errorf("failed: nil IP written as %v", item["Missing"])
+	}
+	var got Host
+	if err := Unmarshal(item, &got); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if !reflect.DeepEqual(got, h) {
+		t.Errorf("failed: round trip\n\t%+v\nwant\n\t%+v", got, h)
+	}
+	item["Addr"] = &dynamodb.AttributeValue{S: aws.String("10.1.2")}
+	if err := Unmarshal(item, &got); err == nil {
+		t.Error("failed: expected error decoding an invalid IP")
+	}
+}
+
+func TestStructSliceRoundTrip(t *testing.T) {
+	type Address struct {
+		Street string
+		Zip    string `dynaGo:"zip"`
+	}
+	type Contact struct {
+		Id        string `dynaGo:",HASH"`
+		Addresses []Address
+		Previous  []*Address
+	}
+	c := Contact{
+		Id:        "c1",
+		Addresses: []Address{{"Main", "02139"}, {"Elm", "10001"}},
+		Previous:  []*Address{{Street: "Old"}, nil},
+	}
+	item := Marshal(c).Item
+	l := item["Addresses"].L
+	if len(l) != 2 || *l[0].M["Street"].S != "Main" || *l[1].M["zip"].S != "10001" {
+		t.Errorf("failed: Addresses encoded as %v", item["Addresses"])
+	}
+	var got Contact
+	if err := Unmarshal(item, &got); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if !reflect.DeepEqual(got, c) {
+		t.Errorf("failed: round trip %+v", got)
+	}
+	item["Addresses"].L[1] = &dynamodb.AttributeValue{S: aws.String("Elm")}
+	if err := Unmarshal(item, &got); err == nil {
+		t.Error("failed: expected error for a list element which isn't a map")
+	}
+}
+
+func TestMapSliceRoundTrip(t *testing.T) {
+	type Form struct {
+		Id      string `dynaGo:",HASH"`
+		Answers []map[string]string
+		Tags    []map[string]struct{}
+	}
+	f := Form{
+		Id:      "f1",
+		Answers: []map[string]string{{"q1": "yes"}, {"q1": "no", "q2": "maybe"}},
+		Tags:    []map[string]struct{}{{"a": {}}},
+	}
+	pi, err := MarshalInto("Forms", f)
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	l := pi.Item["Answers"].L
+	if len(l) != 2 || *l[0].M["q1"].S != "yes" || *l[1].M["q2"].S != "maybe" {
+		t.Errorf("failed: Answers encoded as %v", pi.Item["Answers"])
+	}
+	var got Form
+	if err := Unmarshal(pi.Item, &got); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if !reflect.DeepEqual(got, f) {
+		t.Errorf("failed: round trip %+v", got)
+	}
+	pi.Item["Answers"].L[0] = &dynamodb.AttributeValue{S: aws.String("yes")}
+	if err := Unmarshal(pi.Item, &got); err == nil {
+		t.Error("failed: expected error for a list element which isn't a map")
+	}
+}
+
+func TestRawAttributeRoundTrip(t *testing.T) {
+	type Capture struct {
+		Id    string `dynaGo:",HASH"`
+		Extra *dynamodb.AttributeValue
+		Gone  *dynamodb.AttributeValue
+	}
+	raw := &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{
+		{S: aws.String("a")},
+		{N: aws.String("1")},
+		{BOOL: aws.Bool(true)},
+	}}
+	item := Marshal(Capture{Id: "c1", Extra: raw}).Item
+	if item["Extra"] != raw {
+		t.Errorf("failed: Extra encoded as %v", item["Extra"])
+	}
+	if _, ok := item["Gone"]; ok {
+		t.Error("failed: nil raw attribute should be left out")
+	}
+	item["Gone"] = &dynamodb.AttributeValue{NULL: aws.Bool(true)}
+	var got Capture
+	if err := Unmarshal(item, &got); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if !reflect.DeepEqual(got.Extra, raw) {
+		t.Errorf("failed: Extra decoded as %v", got.Extra)
+	}
+	if got.Gone == nil || got.Gone.NULL == nil {
+		t.Errorf("failed: NULL attribute not captured, got %v", got.Gone)
+	}
+}
+
+func TestFlattenRoundTrip(t *testing.T) {
+	type Address struct {
+		Street string `dynaGo:"street"`
+		Zip    string `dynaGo:"zip"`
+	}
+	type Customer struct {
+		Id      string   `dynaGo:",HASH"`
+		Address Address  `dynaGo:"addr_,flatten"`
+		Billing *Address `dynaGo:"bill_,flatten"`
+	}
+	c := Customer{Id: "c1", Address: Address{"Main", "02139"}, Billing: &Address{Zip: "10001"}}
+	item := Marshal(c).Item
+	if len(item) != 4 || *item["addr_street"].S != "Main" || *item["addr_zip"].S != "02139" || *item["bill_zip"].S != "10001" {
+		t.Errorf("failed: flattened to %v", item)
+	}
+	var got Customer
+	if err := Unmarshal(item, &got); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if !reflect.DeepEqual(got, c) {
+		t.Errorf("failed: round trip %+v", got)
+	}
+	if _, err := std.marshal(Customer{Id: "c2"}); err != nil {
+		t.Errorf("failed: nil flattened pointer, %s", err)
+	}
+	si, err := ScanInput(Customer{}, Filter("Address.Zip", "=", "02139"))
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if *si.FilterExpression != "#n0 = :v0" || *si.ExpressionAttributeNames["#n0"] != "addr_zip" {
+		t.Errorf("failed: filter %q on %v", *si.FilterExpression, si.ExpressionAttributeNames)
+	}
+	ui, err := Update(c).FieldMask("Address").Input()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if *ui.UpdateExpression != "SET #n0 = :v0, #n1 = :v1" || *ui.ExpressionAttributeNames["#n1"] != "addr_zip" {
+		t.Errorf("failed: update %q on %v", *ui.UpdateExpression, ui.ExpressionAttributeNames)
+	}
+	type Bad struct {
+		Id   string   `dynaGo:",HASH"`
+		Tags []string `dynaGo:"t_,flatten"`
+	}
+	if err := Validate(Bad{}); err == nil {
+		t.Error("failed: expected TagOptionError flattening a slice")
+	}
+}
+
+func TestSQLNullRoundTrip(t *testing.T) {
+	type Row struct {
+		Id    string `dynaGo:",HASH"`
+		Name  sql.NullString
+		Count sql.NullInt64
+		Note  sql.NullString `dynaGo:",null"`
+	}
+	valid := Row{Id: "r1", Name: sql.NullString{String: "bob", Valid: true},
+		Count: sql.NullInt64{Int64: 7, Valid: true}, Note: sql.NullString{String: "hi", Valid: true}}
+	item := Marshal(valid).Item
+	if *item["Name"].S != "bob" || *item["Count"].N != "7" || *item["Note"].S != "hi" {
+		t.Errorf("failed: valid encoded as %v", item)
+	}
+	var got Row
+	if err := Unmarshal(item, &got); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if !reflect.DeepEqual(got, valid) {
+		t.Errorf("failed: round trip %+v", got)
+	}
+
+	invalid := Row{Id: "r2"}
+	item = Marshal(invalid).Item
+	if _, ok := item["Name"]; ok {
+		t.Error("failed: invalid NullString should be left out")
+	}
+	if _, ok := item["Count"]; ok {
+		t.Error("failed: invalid NullInt64 should be left out")
+	}
+	if item["Note"] == nil || item["Note"].NULL == nil {
+		t.Errorf("failed: invalid null option field encoded as %v", item["Note"])
+	}
+	got = Row{}
+	if err := Unmarshal(item, &got); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if !reflect.DeepEqual(got, invalid) {
+		t.Errorf("failed: round trip %+v", got)
+	}
+}
+
+func TestBoolSliceRoundTrip(t *testing.T) {
+	type Flags struct {
+		Id    string `dynaGo:",HASH"`
+		Bits  []bool
+		Maybe []*bool
+	}
+	f := Flags{Id: "f1", Bits: []bool{true, false, true}, Maybe: []*bool{aws.Bool(false), nil}}
+	item := Marshal(f).Item
+	l := item["Bits"].L
+	if len(l) != 3 || !*l[0].BOOL || *l[1].BOOL || !*l[2].BOOL {
+		t.Errorf("failed: Bits encoded as %v", item["Bits"])
+	}
+	var got Flags
+	if err := Unmarshal(item, &got); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if !reflect.DeepEqual(got, f) {
+		t.Errorf("failed: round trip %+v", got)
+	}
+	item["Bits"] = &dynamodb.AttributeValue{SS: []*string{aws.String("true")}}
+	if err := Unmarshal(item, &got); err == nil {
+		t.Error("failed: expected error decoding a set into []bool")
+	}
+}
+
+func TestPartialDecode(t *testing.T) {
+	owner := "ann"
+	tk := Ticket{Id: "t1", Status: "closed", Priority: 1, Owner: &owner}
+	prio := "5"
+	if err := Unmarshal(map[string]*dynamodb.AttributeValue{"Priority": {N: &prio}}, &tk); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if tk.Id != "t1" || tk.Status != "closed" || tk.Priority != 5 || tk.Owner != &owner {
+		t.Errorf("failed: partial decode clobbered fields %+v", tk)
+	}
+
+	n := Note{Id: "n1", Text: "kept", Auditable: Auditable{CreatedAt: 10, UpdatedAt: 20}}
+	if err := Unmarshal(map[string]*dynamodb.AttributeValue{"updated": {N: aws.String("30")}}, &n); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if n.Id != "n1" || n.Text != "kept" || n.CreatedAt != 10 || n.UpdatedAt != 30 {
+		t.Errorf("failed: partial decode clobbered fields %+v", n)
+	}
+}
+
+func TestBoolSetMapRoundTrip(t *testing.T) {
+	type Membership struct {
+		Id     string          `dynaGo:",HASH"`
+		Groups map[int]bool    `dynaGo:",set"`
+		Roles  map[string]bool `dynaGo:"roles,set"`
+		Empty  map[string]bool `dynaGo:",set"`
+	}
+	m := Membership{
+		Id:     "m1",
+		Groups: map[int]bool{7: true, 3: true, 9: false},
+		Roles:  map[string]bool{"admin": true, "owner": false, "editor": true},
+		Empty:  map[string]bool{"gone": false},
+	}
+	item := Marshal(m).Item
+	if ns := item["Groups"].NS; len(ns) != 2 || *ns[0] != "3" || *ns[1] != "7" {
+		t.Errorf("failed: Groups encoded as %v", item["Groups"])
+	}
+	if ss := item["roles"].SS; len(ss) != 2 || *ss[0] != "admin" || *ss[1] != "editor" {
+		t.Errorf("failed: Roles encoded as %v", item["roles"])
+	}
+	if _, ok := item["Empty"]; ok {
+		t.Error("failed: a set of no true keys should be left out")
+	}
+	var got Membership
+	if err := Unmarshal(item, &got); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	want := Membership{
+		Id:     "m1",
+		Groups: map[int]bool{3: true, 7: true},
+		Roles:  map[string]bool{"admin": true, "editor": true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("failed: round trip %+v", got)
+	}
+}
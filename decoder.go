@@ -0,0 +1,112 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Decoder carries the configuration used to fill structs from dynamoDB
+// items, the counterpart of Encoder. The package level Unmarshal behaves
+// like the method of a new Decoder.
+type Decoder struct {
+	namer  func(string) string
+	strict bool
+	fold   bool
+	loose  bool
+	cipher FieldCipher
+	// the *structFields of each struct type decoded, named by namer
+	cache *sync.Map
+	// called after each item is unmarshaled, if set
+	decoded func(t reflect.Type, attrs int)
+}
+
+// the Decoder behind the package level functions
+var stdDecoder = NewDecoder()
+
+func NewDecoder() *Decoder {
+	return &Decoder{cache: &sync.Map{}}
+}
+
+// SetNameTransformer matches the attributes of untagged fields by the
+// name f gives their Go field name, as Encoder.SetNameTransformer does
+// when encoding. As with the other settings of dec, this holds for the
+// fields of structs nested in the item too. Fields naming their
+// attribute in the dynaGo tag bypass f. A nil f restores the Go names.
+func (dec *Decoder) SetNameTransformer(f func(goFieldName string) string) {
+	dec.namer = f
+	dec.cache = &sync.Map{}
+}
+
+// SetStrict makes Unmarshal fail with an UnknownAttributeError when the
+// item holds an attribute no field is named for, rather than ignoring
+// it. This catches a Decoder whose naming doesn't match the Encoder that
+// wrote the item, which otherwise decodes to zero values without error.
+func (dec *Decoder) SetStrict(strict bool) {
+	dec.strict = strict
+}
+
+// SetCaseInsensitive matches attributes to fields regardless of case, so
+// an item written by another system with a statuscode attribute fills
+// the StatusCode field. An attribute named exactly for a field is always
+// preferred. Beware of fields whose names differ only by case: each
+// still takes its exact attribute, but when only one of those is present
+// which field it fills is not defined.
+func (dec *Decoder) SetCaseInsensitive(fold bool) {
+	dec.fold = fold
+}
+
+// SetLenientNumbers lets numeric fields (and pointers to them) decode
+// from an S holding a number, as written by systems which store numbers
+// as strings. Otherwise an S for a numeric field is an
+// AttributeTypeError, as is an S which doesn't parse as a number even
+// when lenient. String fields likewise decode from an N, taking its text
+// exactly as stored; the option number (`dynaGo:",number"`) lets a
+// single string field do so without a lenient Decoder.
+func (dec *Decoder) SetLenientNumbers(lenient bool) {
+	dec.loose = lenient
+}
+
+// SetFieldCipher decrypts the attributes of encrypt fields with f, the
+// inverse of the FieldCipher given to Encoder.SetFieldCipher. Decoding
+// an encrypt field without one fails with a FieldCipherError.
+func (dec *Decoder) SetFieldCipher(f FieldCipher) {
+	dec.cipher = f
+}
+
+// OnItemDecoded calls f after each item Unmarshal decodes, with the type
+// decoded into and the number of the item's attributes which filled a
+// field, for metrics or debugging. A nil f, the default, stops the calls.
+func (dec *Decoder) OnItemDecoded(f func(t reflect.Type, attrs int)) {
+	dec.decoded = f
+}
+
+// Clone returns a copy of dec sharing no configuration with it. The
+// fields it has worked out for the types it decoded are copied too, so
+// the clone needn't work them out again.
+func (dec *Decoder) Clone() *Decoder {
+	c := *dec
+	c.cache = &sync.Map{}
+	if dec.cache != nil {
+		dec.cache.Range(func(t, sf interface{}) bool {
+			c.cache.Store(t, sf)
+			return true
+		})
+	}
+	return &c
+}
+
+// the fields of the struct type t, computed on its first decode
+func (dec *Decoder) fields(t reflect.Type) *structFields {
+	if dec.cache == nil {
+		return newStructFields(t, dec.namer)
+	}
+	if sf, ok := dec.cache.Load(t); ok {
+		return sf.(*structFields)
+	}
+	sf, _ := dec.cache.LoadOrStore(t, newStructFields(t, dec.namer))
+	return sf.(*structFields)
+}
@@ -0,0 +1,275 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestDecoderNameTransformer(t *testing.T) {
+	enc := NewEncoder()
+	enc.SetNameTransformer(snakeCase)
+	r := Reading{DeviceId: "d1", TakenAt: 10, SensorName: "temp", MaxValue: 40, IsActive: true}
+	item := enc.Marshal(r).Item
+
+	dec := NewDecoder()
+	dec.SetNameTransformer(snakeCase)
+	dec.SetStrict(true)
+	var out Reading
+	if err := dec.Unmarshal(item, &out); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if out != r {
+		t.Errorf("failed: decoded %+v, want %+v", out, r)
+	}
+
+	// the Go names don't match, strict mode reports it
+	mismatched := NewDecoder()
+	mismatched.SetStrict(true)
+	err := mismatched.Unmarshal(item, &Reading{})
+	if uae, ok := err.(UnknownAttributeError); !ok || uae.AttributeName != "is_active" {
+		t.Errorf("failed: expected UnknownAttributeError for is_active, got %v", err)
+	}
+	// otherwise the unmatched attributes are ignored
+	out = Reading{}
+	if err := Unmarshal(item, &out); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if out.DeviceId != "d1" || out.SensorName != "" {
+		t.Errorf("failed: lenient decode %+v", out)
+	}
+}
+
+func TestDecoderCaseInsensitive(t *testing.T) {
+	type Response struct {
+		Id         string `dynaGo:",HASH"`
+		StatusCode int
+		Status     string
+	}
+	code, id, s, exact := "404", "r1", "missing", "exact"
+	item := map[string]*dynamodb.AttributeValue{
+		"ID":         {S: &id},
+		"statuscode": {N: &code},
+		"status":     {S: &s},
+		"Status":     {S: &exact},
+	}
+	var out Response
+	if err := Unmarshal(item, &out); err != nil || out.StatusCode != 0 {
+		t.Errorf("failed: case sensitive decode %+v %v", out, err)
+	}
+
+	dec := NewDecoder()
+	dec.SetCaseInsensitive(true)
+	out = Response{}
+	if err := dec.Unmarshal(item, &out); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if out.Id != "r1" || out.StatusCode != 404 || out.Status != "exact" {
+		t.Errorf("failed: decoded %+v", out)
+	}
+}
+
+type Versioned struct {
+	*Auditable
+	Version int
+}
+
+type Revision struct {
+	Id string `dynaGo:",HASH"`
+	Versioned
+}
+
+func TestDecoderPromotedFieldPaths(t *testing.T) {
+	r := Revision{Id: "r1", Versioned: Versioned{&Auditable{CreatedAt: 5, UpdatedAt: 6}, 2}}
+	item := Marshal(r).Item
+
+	dec := NewDecoder()
+	var out Revision
+	if err := dec.Unmarshal(item, &out); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if out.Auditable == nil || out.UpdatedAt != 6 || out.CreatedAt != 5 || out.Version != 2 {
+		t.Errorf("failed: decoded %+v", out)
+	}
+	fs := dec.fields(reflect.TypeOf(out))
+	if p := fs.byName["updated"]; !reflect.DeepEqual(p, []int{1, 0, 1}) {
+		t.Errorf("failed: index path of updated %v", p)
+	}
+	if dec.fields(reflect.TypeOf(out)) != fs {
+		t.Error("failed: fields not cached")
+	}
+}
+
+func TestItemCallbacks(t *testing.T) {
+	var types []reflect.Type
+	var counts []int
+	record := func(t reflect.Type, attrs int) {
+		types = append(types, t)
+		counts = append(counts, attrs)
+	}
+	enc := NewEncoder()
+	enc.OnItemEncoded(record)
+	p := Playlist{Id: "p1", Tracks: []string{"a"}}
+	item := enc.Marshal(&p).Item
+	if _, err := enc.Put(p).Input(); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+
+	dec := NewDecoder()
+	dec.OnItemDecoded(record)
+	var out Playlist
+	if err := dec.Unmarshal(item, &out); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	pt := reflect.TypeOf(p)
+	if !reflect.DeepEqual(types, []reflect.Type{pt, pt, pt}) || !reflect.DeepEqual(counts, []int{2, 2, 2}) {
+		t.Errorf("failed: callbacks %v %v", types, counts)
+	}
+
+	enc.OnItemEncoded(nil)
+	enc.Marshal(p)
+	if len(counts) != 3 {
+		t.Errorf("failed: callback fired after being unset")
+	}
+}
+
+func TestDecoderLenientNumbers(t *testing.T) {
+	type Stock struct {
+		Sku   string `dynaGo:",HASH"`
+		Count int
+		Price *float64
+	}
+	sku, count, price := "s1", "42", "9.5"
+	item := map[string]*dynamodb.AttributeValue{
+		"Sku":   {S: &sku},
+		"Count": {S: &count},
+		"Price": {S: &price},
+	}
+	var out Stock
+	err := Unmarshal(item, &out)
+	if _, ok := err.(AttributeTypeError); !ok {
+		t.Errorf("failed: expected AttributeTypeError decoding S into int, got %v", err)
+	}
+
+	dec := NewDecoder()
+	dec.SetLenientNumbers(true)
+	out = Stock{}
+	if err := dec.Unmarshal(item, &out); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if out.Count != 42 || out.Price == nil || *out.Price != 9.5 {
+		t.Errorf("failed: decoded %+v", out)
+	}
+	if item["Count"].S == nil || item["Count"].N != nil {
+		t.Errorf("failed: item modified %v", item["Count"])
+	}
+
+	notNumber := "many"
+	item["Count"] = &dynamodb.AttributeValue{S: &notNumber}
+	if err := dec.Unmarshal(item, &Stock{}); err == nil {
+		t.Error("failed: expected error decoding a non-numeric S into int")
+	}
+}
+
+func TestDecoderNumberStrings(t *testing.T) {
+	type Reading struct {
+		Id    string `dynaGo:",HASH"`
+		Value string
+		Exact *string `dynaGo:",number"`
+	}
+	id, value, exact := "r1", "1.50", "12345678901234567890.000"
+	item := map[string]*dynamodb.AttributeValue{
+		"Id":    {S: &id},
+		"Value": {N: &value},
+		"Exact": {N: &exact},
+	}
+	var out Reading
+	err := Unmarshal(item, &out)
+	if _, ok := err.(AttributeTypeError); !ok {
+		t.Errorf("failed: expected AttributeTypeError decoding N into string, got %v", err)
+	}
+
+	delete(item, "Value")
+	out = Reading{}
+	if err := Unmarshal(item, &out); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if out.Exact == nil || *out.Exact != exact {
+		t.Errorf("failed: decoded %+v", out)
+	}
+
+	item["Value"] = &dynamodb.AttributeValue{N: &value}
+	dec := NewDecoder()
+	dec.SetLenientNumbers(true)
+	out = Reading{}
+	if err := dec.Unmarshal(item, &out); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if out.Value != value || out.Exact == nil || *out.Exact != exact {
+		t.Errorf("failed: decoded %+v", out)
+	}
+	if item["Value"].N == nil || item["Value"].S != nil {
+		t.Errorf("failed: item modified %v", item["Value"])
+	}
+}
+
+func TestDecoderNestedStructs(t *testing.T) {
+	type Profile struct {
+		FirstName string
+		Age       int
+	}
+	type Member struct {
+		Id      string `dynaGo:",HASH"`
+		Profile Profile
+		Past    []Profile
+	}
+	m := Member{Id: "m1", Profile: Profile{"Ann", 30}, Past: []Profile{{"Anne", 29}}}
+
+	enc, dec := Clone()
+	enc.SetNameTransformer(snakeCase)
+	dec.SetNameTransformer(snakeCase)
+	item := enc.Marshal(m).Item
+	if _, ok := item["profile"].M["first_name"]; !ok {
+		t.Fatalf("failed: nested fields named %v", item["profile"])
+	}
+	var got Member
+	if err := dec.Unmarshal(item, &got); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("failed: round trip %+v", got)
+	}
+
+	item = Marshal(m).Item
+	item["Profile"].M["firstname"] = item["Profile"].M["FirstName"]
+	delete(item["Profile"].M, "FirstName")
+	dec = NewDecoder()
+	dec.SetCaseInsensitive(true)
+	got = Member{}
+	if err := dec.Unmarshal(item, &got); err != nil || got.Profile.FirstName != "Ann" {
+		t.Errorf("failed: case insensitive nested decode %+v %v", got, err)
+	}
+
+	age := "30"
+	item["Profile"].M["Age"] = &dynamodb.AttributeValue{S: &age}
+	dec.SetLenientNumbers(true)
+	got = Member{}
+	if err := dec.Unmarshal(item, &got); err != nil || got.Profile.Age != 30 {
+		t.Errorf("failed: lenient nested decode %+v %v", got, err)
+	}
+
+	dec = NewDecoder()
+	dec.SetStrict(true)
+	item = Marshal(m).Item
+	item["Profile"].M["Nickname"] = &dynamodb.AttributeValue{S: &age}
+	if err := dec.Unmarshal(item, &Member{}); err == nil {
+		t.Error("failed: expected UnknownAttributeError for a nested attribute")
+	} else if _, ok := err.(UnknownAttributeError); !ok {
+		t.Errorf("failed: expected UnknownAttributeError, got %v", err)
+	}
+}
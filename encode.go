@@ -8,9 +8,13 @@ import (
 	"errors"
 	"os"
 	"reflect"
+	"regexp"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
 // Marshal returns a dynamodb.PutItemInput representitive of i
@@ -20,35 +24,123 @@ import (
 // Field tags are modeled after the encoding/json package as
 // follows:  A field may have a different name as a dynamoDB
 // attribute.  This name can be specified with the field tag
-//   `dynaGo:"[alt-name]"`
+//
+//	`dynaGo:"[alt-name]"`
+//
 // Any options in the field tag (such as HASH, or RANGE) must
 // be specified after a comma. If the attribute name remains
 // the same, then the tage must begin with a leading comma to
 // indicate the presence of options:
-//   `dynaGo:",HASH"`
-//   `dynaGo:"[alt-name],HASH"
+//
+//	`dynaGo:",HASH"`
+//	`dynaGo:"[alt-name],HASH"
+//
 // for more examples see https://golang.org/pkg/encoding/json/
+// Fields with the option readonly (`dynaGo:",readonly"`) are left out of
+// the item, though Unmarshal still fills them. Slices are stored as sets
+// unless given the option list, which keeps their order as an L.
+// The fields of an embedded struct are stored as attributes of the item
+// itself, as encoding/json promotes them. Fields with the option
+// omitempty are left out when they hold their zero value (0, false, ...)
+// as well as when empty, which keeps items out of a sparse index keyed
+// by the field. Keys of the table itself cannot be omitempty. Integers
+// with the option string (and optionally pad=N) are stored as strings,
+// see stringIntValueEncoder, as are time.Durations with the option, see
+// durationStringValueEncoder. Fields with the option encrypt are stored
+// as a B of their cipher text, see Encoder.SetFieldCipher.
 //
 // Table names will simply be composed of the struct name plus
 // the letter s.  For instance if there is a
-//   type Packet struct {...}
+//
+//	type Packet struct {...}
+//
 // the associatedd dynamoDB table will be named "Packets" (for now?)
 //
 // Immediately this method only recognizes struct types that are
 // composed of exculsively int, string, and structs or slices and
 // pointers to any of those types. Any further unexpected type
 // will trigger a panic. Additional types should be trivial to add
-// following the given pattern.
+// following the given pattern. Beyond those kinds, big.Int and
+// big.Float (or pointers to them) are stored as Numbers, and panic
+// with a NumberPrecisionError past DynamoDB's 38 digits of precision.
+// net.IP, net.IPNet and url.URL values are stored as strings, see
+// ipValueEncoder.
 func Marshal(i interface{}) *dynamodb.PutItemInput {
-	e := &valueEncoderState{make(map[string]*dynamodb.AttributeValue)}
-	encode(e, i)
-	tn := TableName(reflect.TypeOf(i))
-	return &dynamodb.PutItemInput{Item: e.item, TableName: &tn}
+	return std.Marshal(i)
+}
+
+// MarshalInto is Marshal with the item put in the table named tableName,
+// exactly as given (no prefix is applied), rather than the table named
+// after its type. This writes a struct to a shadow or audit table of the
+// same shape. Encoding failures are returned rather than panicking.
+func MarshalInto(tableName string, i interface{}) (*dynamodb.PutItemInput, error) {
+	return std.MarshalInto(tableName, i)
+}
+
+// MarshalFragment returns the attributes of the struct (or pointer to
+// struct) i as Marshal would write them, but as a bare map rather than a
+// PutItemInput, so needing no table: i may lack a HASH key. This suits
+// sub-objects embedded in an M by hand, and the values of filter
+// expressions. Encoding failures are returned rather than panicking.
+func MarshalFragment(i interface{}) (map[string]*dynamodb.AttributeValue, error) {
+	return std.MarshalFragment(i)
+}
+
+// MarshalDynamic returns a PutItemInput for the schemaless record m,
+// written to the table named tableName exactly as given (no prefix is
+// applied). Each value is encoded according to its own type: nested
+// map[string]interface{} values become maps, []interface{} values become
+// lists, and nil values are omitted. m must include the table's key
+// attributes itself.
+func MarshalDynamic(tableName string, m map[string]interface{}) (pi *dynamodb.PutItemInput, err error) {
+	defer recoverError(&err)
+	e := &valueEncoderState{item: make(map[string]*dynamodb.AttributeValue)}
+	for k, i := range m {
+		if i == nil {
+			continue
+		}
+		v := reflect.ValueOf(i)
+		valueEncoder(v.Type())(e, k, v)
+	}
+	return &dynamodb.PutItemInput{Item: e.item, TableName: &tableName}, nil
+}
+
+// MarshalValue returns the AttributeValue of a single value v, encoded
+// as a struct field of its type would be: a string as S, numbers as N,
+// a bool as BOOL, []byte as B, other slices as sets, maps and keyless
+// structs as M, and a struct with a HASH key as that key. This suits
+// values for hand built expressions. An empty string is S "" (though
+// Marshal leaves such a field out), other values which encode to
+// nothing, such as a nil pointer or empty slice, are an EmptyValueError.
+func MarshalValue(v interface{}) (*dynamodb.AttributeValue, error) {
+	return marshalValue(v, nil)
+}
+
+// MarshalValue with the fields of structs named by namer
+func marshalValue(v interface{}, namer func(string) string) (av *dynamodb.AttributeValue, err error) {
+	defer recoverError(&err)
+	if v == nil {
+		return nil, &UnsupportedKindError{reflect.Invalid}
+	}
+	rv := reflect.ValueOf(v)
+	e := &valueEncoderState{make(map[string]*dynamodb.AttributeValue), namer}
+	valueEncoder(rv.Type())(e, "", rv)
+	av, ok := e.item[""]
+	if !ok {
+		// only empty strings are dropped by the scalar encoders
+		if rv.Kind() == reflect.String {
+			s := ""
+			return &dynamodb.AttributeValue{S: &s}, nil
+		}
+		return nil, &EmptyValueError{rv.Type()}
+	}
+	return av, nil
 }
 
 var (
-	prefix string
-	once   sync.Once
+	prefix    string
+	prefixSet bool
+	once      sync.Once
 )
 
 const (
@@ -57,27 +149,45 @@ const (
 
 func tablePrefix() string {
 	once.Do(func() {
-		// if the prefex isn't set, just have a tantrum
-		if _, ok := os.LookupEnv(dynaGoPrefix); !ok {
-			panic("env DNYAGO_PREFIX not set - no valid table prefix provided in environment")
-		}
 		//fetch the value in ENVIRONMENT - whatever that ended up being.
-		prefix = os.Getenv(dynaGoPrefix) + "_"
+		var p string
+		p, prefixSet = os.LookupEnv(dynaGoPrefix)
+		prefix = p + "_"
 	})
+	// if the prefex isn't set, just have a tantrum
+	// the error returning functions recover this as a PrefixNotSetError
+	if !prefixSet {
+		panic(&PrefixNotSetError{dynaGoPrefix})
+	}
 	return prefix
 }
 
 func TableName(t reflect.Type) string {
-	if t.Kind() == reflect.Ptr {
-		t = t.Elem()
-	}
-	return tablePrefix() + t.Name() + "s"
+	return std.TableName(t)
+}
+
+// TableNames lists the tables holding vs, for tools creating or migrating
+// every table of a model, see Encoder.TableNames.
+func TableNames(vs ...interface{}) []string {
+	return std.TableNames(vs...)
+}
+
+// SetDefaultTTL sets the default TTL of the package level functions, see
+// Encoder.SetDefaultTTL.
+func SetDefaultTTL(d time.Duration) {
+	std.SetDefaultTTL(d)
+}
+
+// SetTableSuffix sets the suffix of the tables named by the package level
+// functions, see Encoder.SetTableSuffix.
+func SetTableSuffix(suffix string) {
+	std.SetTableSuffix(suffix)
 }
 
 // Try to create a table if it doesn't already exist
 // If it does exist or cannot be created, return error
 //
-// Tables are created from structs only, and will panic on any other type
+// # Tables are created from structs only, and will panic on any other type
 //
 // Table name will be [structName] + s (ie type Doc struct {...} => table "Docs")
 func CreateTable(svc *dynamodb.DynamoDB, v interface{}, w int64, r int64) error {
@@ -85,33 +195,119 @@ func CreateTable(svc *dynamodb.DynamoDB, v interface{}, w int64, r int64) error
 	if err := tableExists(svc, tn); err != nil {
 		return err
 	}
-	e := &tableEncoderState{
-		keySchema:            make([]*dynamodb.KeySchemaElement, 0),
-		attributeDefinitions: make([]*dynamodb.AttributeDefinition, 0),
-	}
-	encode(e, v)
-	params := &dynamodb.CreateTableInput{
-		TableName:            &tn,
-		KeySchema:            e.keySchema,
-		AttributeDefinitions: e.attributeDefinitions,
-		ProvisionedThroughput: &dynamodb.ProvisionedThroughput{
-			ReadCapacityUnits:  &r,
-			WriteCapacityUnits: &w,
-		},
-	}
+	params := createTableInput(v, w, r)
 	if _, err := svc.CreateTable(params); err != nil {
 		return err
 	}
 	return nil
 }
 
+// EnsureTable creates the table for v as CreateTable does, but treats a
+// table which already exists as success, which is what most startup code
+// wants. The existing table's primary key is checked against v though,
+// and a SchemaMismatchError returned if they differ. CreateTable remains
+// strict.
+func EnsureTable(svc dynamodbiface.DynamoDBAPI, v interface{}, w int64, r int64) error {
+	tn, err := std.tableName(reflect.TypeOf(v))
+	if err != nil {
+		return err
+	}
+	params, err := BuildCreateTableInput(v, w, r)
+	if err != nil {
+		return err
+	}
+	err = tableExists(svc, tn)
+	if _, ok := err.(TableExistsError); ok {
+		resp, err := svc.DescribeTable(&dynamodb.DescribeTableInput{TableName: &tn})
+		if err != nil {
+			return err
+		}
+		want, got := keySchemaString(params.KeySchema), keySchemaString(resp.Table.KeySchema)
+		if want != got {
+			return &SchemaMismatchError{tn, want, got}
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = svc.CreateTable(params)
+	return err
+}
+
+// BuildCreateTableInput returns the CreateTableInput CreateTable would
+// send for v, without contacting dynamoDB. The key schema comes from the
+// HASH and RANGE tags, along with any secondary indexes tagged as
+// `dynaGo:",HASH=IndexName"` (global) or `dynaGo:",RANGE=IndexName"`
+// (local, when the index has no HASH). Indexes project ALL attributes.
+// Global indexes are given the table's throughput unless tagged with
+// their own rcu or wcu, and a w and r of 0 create the table on demand
+// (PAY_PER_REQUEST), without any throughput. AttributeDefinitions hold exactly
+// the attributes used by these keys. Failures are returned as an error
+// rather than a panic.
+func BuildCreateTableInput(v interface{}, w int64, r int64) (params *dynamodb.CreateTableInput, err error) {
+	defer recoverError(&err)
+	return createTableInput(v, w, r), nil
+}
+
+// BuildCreateTableInputWithKeys returns a CreateTableInput for items of
+// v keyed by other fields than its tags declare: the Go fields hashField
+// and, unless it is "", rangeField. This suits a second table mirroring
+// the items of v under another key (a materialized index). The fields
+// must exist and be scalar, a string or integer, or a []byte with the
+// option type=B. The table is named for v, as BuildCreateTableInput names
+// it, and usually renamed by the caller. Secondary indexes tagged on v
+// belong to its own table and are left out. w and r are as for
+// BuildCreateTableInput.
+func BuildCreateTableInputWithKeys(v interface{}, hashField, rangeField string, w, r int64) (params *dynamodb.CreateTableInput, err error) {
+	defer recoverError(&err)
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, &OnlyStructsSupportedError{reflect.ValueOf(v).Kind()}
+	}
+	e := &tableEncoderState{}
+	for _, k := range []struct{ field, kt string }{
+		{hashField, dynamodb.KeyTypeHash},
+		{rangeField, dynamodb.KeyTypeRange},
+	} {
+		if k.field == "" && k.kt == dynamodb.KeyTypeRange {
+			continue
+		}
+		sf, ok := t.FieldByName(k.field)
+		if !ok {
+			return nil, &UnknownFieldError{t, k.field}
+		}
+		st, ok := keyScalarType(sf)
+		if !ok {
+			return nil, &TableKeyCannotBeTypeError{sf.Type}
+		}
+		an, kt := getAttrName(sf), k.kt
+		e.keySchema = append(e.keySchema, &dynamodb.KeySchemaElement{AttributeName: &an, KeyType: &kt})
+		e.define(an, st)
+	}
+	return e.createTableInput(TableName(t), w, r), nil
+}
+
+func createTableInput(v interface{}, w int64, r int64) *dynamodb.CreateTableInput {
+	tn := TableName(reflect.TypeOf(v))
+	e := &tableEncoderState{
+		keySchema:            make([]*dynamodb.KeySchemaElement, 0),
+		attributeDefinitions: make([]*dynamodb.AttributeDefinition, 0),
+	}
+	encode(e, v, nil)
+	return e.createTableInput(tn, w, r)
+}
+
 type encoderState interface{}
 type fieldTransform func(fs reflect.StructField, v reflect.Value) bool
 
 // Concerned with encoding structs to 2 types:
 // dynamoDB Tables, and dynamoDB Values by way of
 // tableEncoderState and valueEncoderState respectively
-func encode(e encoderState, i interface{}) {
+func encode(e encoderState, i interface{}, namer func(string) string) {
 	foundPKey := false
 	v := reflect.ValueOf(i)
 	t := v.Type()
@@ -132,31 +328,302 @@ func encode(e encoderState, i interface{}) {
 	switch es := e.(type) {
 	case *tableEncoderState:
 		ftr = func(fs reflect.StructField, fv reflect.Value) bool {
-			str := tableEncoder(fs.Type)(es, fs, fv)
+			str := fieldTableEncoder(fs)(es, fs, fv)
 			return str == dynamodb.KeyTypeHash
 		}
 	case *valueEncoderState:
 		ftr = func(fs reflect.StructField, fv reflect.Value) bool {
-			fn := getAttrName(fs)
-			valueEncoder(fs.Type)(es, fn, fv)
+			if isReadOnly(fs) {
+				return true
+			}
+			fn := attrName(fs, namer)
+			fieldValueEncoder(fs)(es, fn, fv)
 			return true
 		}
 	default:
 		panic(&InvalidEncoderStateType{et})
 	}
-	for n := 0; n < t.NumField(); n++ {
-		fs, fv := t.Field(n), v.Field(n)
+	checkAttrNames(t, namer)
+	for _, fs := range attrFields(t) {
+		fv, ok := fieldByIndex(v, fs.Index)
+		if !ok {
+			// an unset embedded struct has no values to write, but its
+			// fields are still part of the table
+			if _, table := e.(*tableEncoderState); !table {
+				continue
+			}
+			fv = reflect.Zero(fs.Type)
+		}
+		if cv, ok := composedKey(v, fs); ok {
+			fv = cv
+		}
 		// expect to find a primary key
 		foundPKey = ftr(fs, fv) || foundPKey
 	}
 	if !foundPKey {
-		panic(&MissingKeyError{t, dynamodb.KeyTypeHash})
+		panic(missingKeyError(t, dynamodb.KeyTypeHash))
 	}
 }
 
+// An encodePlan is the encoding of the fields of a struct type worked out
+// once, for marshaling many items of the type as encode would.
+type encodePlan struct {
+	fields []plannedField
+	cipher FieldCipher
+	t      reflect.Type
+	namer  func(string) string
+	ttl    time.Duration
+	// called after each item is encoded, if set, see OnItemEncoded
+	encoded func(t reflect.Type, attrs int)
+}
+
+type plannedField struct {
+	sf       reflect.StructField
+	name     string
+	enc      valueEncoderFunc
+	composed bool
+	encrypt  bool
+}
+
+func newEncodePlan(t reflect.Type, namer func(string) string) *encodePlan {
+	checkAttrNames(t, namer)
+	p := &encodePlan{t: t, namer: namer}
+	for _, fs := range attrFields(t) {
+		if isReadOnly(fs) {
+			continue
+		}
+		_, o := parseTag(fs.Tag.Get("dynaGo"))
+		p.fields = append(p.fields, plannedField{
+			sf:       fs,
+			name:     attrName(fs, namer),
+			enc:      fieldValueEncoder(fs),
+			composed: len(o.Values("compose")) > 0 || len(o.Values("shards")) > 0,
+			encrypt:  isEncrypted(fs),
+		})
+	}
+	return p
+}
+
+// the item of the struct v, of the type planned for
+func (p *encodePlan) encode(v reflect.Value) map[string]*dynamodb.AttributeValue {
+	e := &valueEncoderState{make(map[string]*dynamodb.AttributeValue, len(p.fields)), p.namer}
+	for _, f := range p.fields {
+		fv, ok := fieldByIndex(v, f.sf.Index)
+		if !ok {
+			continue
+		}
+		if f.composed {
+			fv, _ = composedKey(v, f.sf)
+		}
+		f.enc(e, f.name, fv)
+		if av, ok := e.item[f.name]; ok && f.encrypt {
+			e.item[f.name] = encryptAttribute(f.sf, f.name, av, p.cipher)
+		}
+	}
+	fillTTL(e.item, p.t, p.namer, p.ttl)
+	if p.encoded != nil {
+		p.encoded(p.t, len(e.item))
+	}
+	return e.item
+}
+
+// Validate reports whether i (a struct or pointer to struct) can be
+// stored by dynaGo without losing data: it must declare a HASH key, and
+// no two of its fields may resolve to the same attribute name, as the
+// second would silently overwrite the first. Each attribute name must be
+// within DynamoDB's limits (which Marshal also enforces), and is flagged
+// with an AttributeNameError if it holds characters (or begins with one)
+// that an expression written by hand would need to alias with #name.
+func Validate(i interface{}) (err error) {
+	defer recoverError(&err)
+	t := reflect.TypeOf(i)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return &OnlyStructsSupportedError{reflect.Invalid}
+	}
+	if t.Kind() != reflect.Struct {
+		return &OnlyStructsSupportedError{t.Kind()}
+	}
+	checkAttrNames(t, nil)
+	for _, fs := range attrFields(t) {
+		if an := attrName(fs, nil); !plainAttrName.MatchString(an) {
+			return &AttributeNameError{t, fs.Name, an, "needs aliasing in expressions"}
+		}
+	}
+	getPartitionKey(t)
+	return nil
+}
+
 //-- UTIL --//
+
+// DynamoDB's bounds on the length in bytes of attribute names, those of
+// keys being shorter
+const (
+	maxAttrNameLen    = 65535
+	maxKeyAttrNameLen = 255
+)
+
+// attribute names usable in expressions as they are, without aliasing
+var plainAttrName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// panics with an AttributeNameError if an attribute name of the struct
+// type t is empty or too long, a DuplicateAttributeError naming both
+// fields if two fields of t share an attribute name, with an
+// OmitEmptyKeyError if a key of the table may be omitted, and with a
+// TagOptionError if a key of the table is to be encrypted, is a
+// time.Duration stored as a string, or is sharded without a RANGE, or if
+// a TTL field can't be one (see isTTL).
+func checkAttrNames(t reflect.Type, namer func(string) string) {
+	fields := make(map[string]string, t.NumField())
+	ttl := ""
+	for _, fs := range attrFields(t) {
+		if isTTL(fs) {
+			checkTTL(fs)
+			if ttl != "" {
+				panic(&TagOptionError{fs.Name, "TTL"})
+			}
+			ttl = fs.Name
+		}
+		if isKeyField(fs) && isOmitEmpty(fs) {
+			panic(&OmitEmptyKeyError{t, fs.Name})
+		}
+		if isKeyField(fs) && isEncrypted(fs) {
+			panic(&TagOptionError{fs.Name, "encrypt"})
+		}
+		if isKeyField(fs) && isDurationString(fs) {
+			panic(&TagOptionError{fs.Name, "string"})
+		}
+		if n, _, ok := keyShards(fs); ok {
+			if _, err := getRangeKey(t); err != nil {
+				panic(&TagOptionError{fs.Name, "shards=" + strconv.Itoa(n)})
+			}
+		}
+		an := attrName(fs, namer)
+		max := maxAttrNameLen
+		if isKeyField(fs) {
+			max = maxKeyAttrNameLen
+		}
+		switch {
+		case an == "":
+			panic(&AttributeNameError{t, fs.Name, an, "is empty"})
+		case len(an) > max:
+			panic(&AttributeNameError{t, fs.Name, an, "is longer than " + strconv.Itoa(max) + " bytes"})
+		}
+		if f, ok := fields[an]; ok {
+			panic(&DuplicateAttributeError{t, an, f, fs.Name})
+		}
+		fields[an] = fs.Name
+	}
+}
+
+// The fields of the struct type t stored as attributes. As with
+// encoding/json, the fields of an embedded struct (or pointer to struct)
+// are promoted into its place, their Index the path from t. An embedded
+// struct named in its tag, or with a HASH key of its own, is stored as a
+// field. The fields of a struct field with the option flatten are
+// promoted too, with the name in its tag prefixed to their attribute
+// names, see flattenedStruct.
+func attrFields(t reflect.Type) []reflect.StructField {
+	fs := make([]reflect.StructField, 0, t.NumField())
+	for n := 0; n < t.NumField(); n++ {
+		sf := t.Field(n)
+		if ft, prefix, ok := flattenedStruct(sf); ok {
+			for _, ff := range attrFields(ft) {
+				ff.Index = append([]int{n}, ff.Index...)
+				ff.Tag = prefixedTag(ff, prefix)
+				fs = append(fs, ff)
+			}
+			continue
+		}
+		if et, ok := embeddedStruct(sf); ok {
+			for _, ef := range attrFields(et) {
+				ef.Index = append([]int{n}, ef.Index...)
+				fs = append(fs, ef)
+			}
+			continue
+		}
+		fs = append(fs, sf)
+	}
+	return fs
+}
+
+// A struct field tagged `dynaGo:"addr_,flatten"` stores the fields of its
+// struct (or pointer to struct) in the item alongside its own, their
+// attribute names prefixed with addr_, where they can be queried and
+// indexed as a Map's can't. The prefix stands in for the name of the
+// field, so the name transformer of an Encoder or Decoder isn't applied
+// to the flattened fields. Only a struct which would be stored as a Map
+// can be flattened, anything else panics with a TagOptionError.
+func flattenedStruct(sf reflect.StructField) (reflect.Type, string, bool) {
+	prefix, o := parseTag(sf.Tag.Get("dynaGo"))
+	if !o.Contains("flatten") {
+		return nil, "", false
+	}
+	if !storedAsMap(sf.Type) {
+		panic(&TagOptionError{sf.Name, "flatten"})
+	}
+	t := sf.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t, prefix, true
+}
+
+// the dynaGo tag of the flattened field sf, naming its attribute with
+// prefix before the name it would otherwise have
+func prefixedTag(sf reflect.StructField, prefix string) reflect.StructTag {
+	name, o := parseTag(sf.Tag.Get("dynaGo"))
+	if name == "" {
+		name = sf.Name
+	}
+	tag := prefix + name
+	if o != "" {
+		tag += "," + string(o)
+	}
+	return reflect.StructTag("dynaGo:" + strconv.Quote(tag))
+}
+
+// the struct type of sf when its fields are promoted by attrFields
+func embeddedStruct(sf reflect.StructField) (reflect.Type, bool) {
+	if !sf.Anonymous {
+		return nil, false
+	}
+	if name, _ := parseTag(sf.Tag.Get("dynaGo")); name != "" {
+		return nil, false
+	}
+	t := sf.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case bigIntType, bigFloatType, timeType, ipNetType, urlType:
+		return nil, false
+	}
+	if t.Kind() != reflect.Struct || hasPartitionKey(t) {
+		return nil, false
+	}
+	return t, true
+}
+
+// v.FieldByIndex, which reports false instead of panicking when the path
+// passes through a nil pointer (an unset embedded struct)
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for n, i := range index {
+		if n > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v, true
+}
+
 // could be cached
-func tableExists(svc *dynamodb.DynamoDB, tn string) error {
+func tableExists(svc dynamodbiface.DynamoDBAPI, tn string) error {
 	params := &dynamodb.ListTablesInput{}
 	resp, err := svc.ListTables(params)
 	if err != nil {
@@ -170,6 +637,18 @@ func tableExists(svc *dynamodb.DynamoDB, tn string) error {
 	return nil
 }
 
+// describes a key schema as "Name:HASH Name:RANGE" for comparison
+func keySchemaString(ks []*dynamodb.KeySchemaElement) string {
+	s := ""
+	for n, k := range ks {
+		if n > 0 {
+			s += " "
+		}
+		s += *k.AttributeName + ":" + *k.KeyType
+	}
+	return s
+}
+
 // The dynamoDB attribute name is determined by:
 // if the field tags contains a name use that name
 // if not, just use the native GoLang field name
@@ -177,12 +656,21 @@ func tableExists(svc *dynamodb.DynamoDB, tn string) error {
 // "HASH", or "RANGE" as this is assumed to be a
 // mistake (missing leading comma in field tag)
 func getAttrName(s reflect.StructField) string {
+	return attrName(s, nil)
+}
+
+// getAttrName, with the Go name of untagged fields passed through namer
+// when it isn't nil. A name given in the tag is always used as is.
+func attrName(s reflect.StructField, namer func(string) string) string {
 	fn, _ := parseTag(s.Tag.Get("dynaGo"))
 	if fn == dynamodb.KeyTypeHash || fn == dynamodb.KeyTypeRange {
 		panic(&FieldNameCannotBeError{fn})
 	}
 	if fn == "" {
 		fn = s.Name
+		if namer != nil {
+			fn = namer(fn)
+		}
 	}
 	return fn
 }
@@ -191,6 +679,7 @@ func getAttrName(s reflect.StructField) string {
 // if it is return the type from the below set
 //   - dynamodb.KeyTypeHash
 //   - dynamoDB.KeyTypeRange
+//
 // if it is not, return "" and an error
 func getKeyType(s reflect.StructField, v reflect.Value) (string, error) {
 	_, o := parseTag(s.Tag.Get("dynaGo"))
@@ -202,3 +691,28 @@ func getKeyType(s reflect.StructField, v reflect.Value) (string, error) {
 	}
 	return "", &KeyTypeNotFoundError{v.Type()}
 }
+
+// reports whether the field is tagged as either part of the primary key
+func isKeyField(s reflect.StructField) bool {
+	_, o := parseTag(s.Tag.Get("dynaGo"))
+	return o.Contains(dynamodb.KeyTypeHash) || o.Contains(dynamodb.KeyTypeRange)
+}
+
+// reports whether the field is tagged as a key of any secondary index
+func isIndexKeyField(s reflect.StructField) bool {
+	_, o := parseTag(s.Tag.Get("dynaGo"))
+	return len(o.Values(dynamodb.KeyTypeHash)) > 0 || len(o.Values(dynamodb.KeyTypeRange)) > 0
+}
+
+func isOmitEmpty(s reflect.StructField) bool {
+	_, o := parseTag(s.Tag.Get("dynaGo"))
+	return o.Contains("omitempty")
+}
+
+// readonly fields are filled by Unmarshal but never written by Marshal or
+// Update, for attributes maintained by some other path (a composite sort
+// key, a denormalized copy). A readonly key stays in the table's schema.
+func isReadOnly(s reflect.StructField) bool {
+	_, o := parseTag(s.Tag.Get("dynaGo"))
+	return o.Contains("readonly")
+}
@@ -6,6 +6,8 @@ package dynaGo
 
 import (
 	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
@@ -13,6 +15,29 @@ import (
 type tableEncoderState struct {
 	keySchema            []*dynamodb.KeySchemaElement
 	attributeDefinitions []*dynamodb.AttributeDefinition
+	// secondary indexes in the order they are first tagged
+	indexes []*indexSchema
+}
+
+// Secondary indexes are declared by naming the index in a key option,
+//
+//	`dynaGo:",HASH=ByOrigin"` or `dynaGo:",RANGE=ByOrigin"`
+//
+// an index with a HASH is global, and one with only a RANGE is local
+// (sharing the table's HASH). A field can be a key of several indexes.
+//
+// A global index is provisioned with the table's throughput unless a key
+// field of the index sets its own read or write capacity units,
+//
+//	`dynaGo:",HASH=ByOrigin,rcu=ByOrigin:10,wcu=ByOrigin:2"`
+//
+// Local indexes always share the throughput of the table.
+type indexSchema struct {
+	name string
+	hash *dynamodb.KeySchemaElement
+	rng  *dynamodb.KeySchemaElement
+
+	read, write *int64
 }
 
 func (e *tableEncoderState) Error(err error) {
@@ -21,7 +46,8 @@ func (e *tableEncoderState) Error(err error) {
 
 func tableEncoder(t reflect.Type) tableEncoderFunc {
 	switch t.Kind() {
-	case reflect.Slice, reflect.Map:
+	case reflect.Slice, reflect.Array, reflect.Map,
+		reflect.Bool, reflect.Float32, reflect.Float64, reflect.Interface:
 		return notAllowedTableEncoder
 	case reflect.Struct:
 		return structTableEncoder
@@ -36,9 +62,73 @@ func tableEncoder(t reflect.Type) tableEncoderFunc {
 	}
 }
 
+// The scalar type of a key attribute is inferred from the kind of its
+// field, or stated with the option type=S, N or B, as a []byte key must
+// be (`dynaGo:",HASH,type=B"`). The type must suit the field: S a string
+// (or an integer stored as one), N an integer and B a byte slice or
+// array. fieldTableEncoder returns the tableEncoderFunc for the field sf.
+func fieldTableEncoder(sf reflect.StructField) tableEncoderFunc {
+	_, o := parseTag(sf.Tag.Get("dynaGo"))
+	ts := o.Values("type")
+	if len(ts) == 0 {
+		return tableEncoder(sf.Type)
+	}
+	st := ts[0]
+	if !scalarTypeFits(sf, st) {
+		panic(&TagOptionError{sf.Name, "type=" + st})
+	}
+	return func(e *tableEncoderState, s reflect.StructField, v reflect.Value) string {
+		return attributeEncoder(e, s, v, st)
+	}
+}
+
+// reports whether the field sf can be stored as the scalar type st
+func scalarTypeFits(sf reflect.StructField, st string) bool {
+	t := sf.Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	_, str := stringInt(sf)
+	switch st {
+	case dynamodb.ScalarAttributeTypeS:
+		return t.Kind() == reflect.String || str
+	case dynamodb.ScalarAttributeTypeN:
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return !str
+		}
+	case dynamodb.ScalarAttributeTypeB:
+		return isBytes(t)
+	}
+	return false
+}
+
+// the scalar type of the field sf as a key attribute, as stated by its
+// type option or inferred from its kind, false if sf can't be a key
+func keyScalarType(sf reflect.StructField) (string, bool) {
+	_, o := parseTag(sf.Tag.Get("dynaGo"))
+	if ts := o.Values("type"); len(ts) > 0 {
+		return ts[0], scalarTypeFits(sf, ts[0])
+	}
+	for _, st := range []string{dynamodb.ScalarAttributeTypeS, dynamodb.ScalarAttributeTypeN} {
+		if scalarTypeFits(sf, st) {
+			return st, true
+		}
+	}
+	return "", false
+}
+
+// reports whether t is a byte slice or array, stored as a B
+func isBytes(t reflect.Type) bool {
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() == reflect.Uint8
+}
+
 type tableEncoderFunc func(e *tableEncoderState, s reflect.StructField, v reflect.Value) string
 
 func intTableEncoder(e *tableEncoderState, s reflect.StructField, v reflect.Value) string {
+	if _, ok := stringInt(s); ok {
+		return attributeEncoder(e, s, v, dynamodb.ScalarAttributeTypeS)
+	}
 	return attributeEncoder(e, s, v, dynamodb.ScalarAttributeTypeN)
 }
 func stringTableEncoder(e *tableEncoderState, s reflect.StructField, v reflect.Value) string {
@@ -48,7 +138,7 @@ func structTableEncoder(e *tableEncoderState, s reflect.StructField, v reflect.V
 	return attributeEncoder(e, s, v, dynamodb.ScalarAttributeTypeS)
 }
 func notAllowedTableEncoder(e *tableEncoderState, s reflect.StructField, v reflect.Value) string {
-	if _, err := getKeyType(s, v); err == nil {
+	if _, err := getKeyType(s, v); err == nil || isIndexKeyField(s) {
 		e.Error(&TableKeyCannotBeTypeError{v.Type()})
 	}
 	return ""
@@ -77,6 +167,20 @@ func tableUnsupportedTypeEncoder(e *tableEncoderState, s reflect.StructField, v
 
 func attributeEncoder(e *tableEncoderState, s reflect.StructField, v reflect.Value, st string) string {
 	an := getAttrName(s)
+	_, o := parseTag(s.Tag.Get("dynaGo"))
+	for _, kt := range []string{dynamodb.KeyTypeHash, dynamodb.KeyTypeRange} {
+		for _, in := range o.Values(kt) {
+			e.indexKey(in, an, kt, st)
+		}
+	}
+	for _, v := range o.Values("rcu") {
+		in, u := indexCapacity(v)
+		e.index(in).read = &u
+	}
+	for _, v := range o.Values("wcu") {
+		in, u := indexCapacity(v)
+		e.index(in).write = &u
+	}
 	kt, err := getKeyType(s, v)
 	//if this is not a key attribute, the table schema doesn't care
 	if err != nil {
@@ -87,10 +191,130 @@ func attributeEncoder(e *tableEncoderState, s reflect.StructField, v reflect.Val
 			AttributeName: &an,
 			KeyType:       &kt,
 		})
+	e.define(an, st)
+	return kt
+}
+
+// splits the value of an rcu or wcu option into its index and units
+func indexCapacity(v string) (string, int64) {
+	i := strings.LastIndex(v, ":")
+	if i <= 0 {
+		panic(&IndexThroughputError{v})
+	}
+	u, err := strconv.ParseInt(v[i+1:], 10, 64)
+	if err != nil || u <= 0 {
+		panic(&IndexThroughputError{v})
+	}
+	return v[:i], u
+}
+
+// the index named in, added when first seen
+func (e *tableEncoderState) index(in string) *indexSchema {
+	for _, i := range e.indexes {
+		if i.name == in {
+			return i
+		}
+	}
+	is := &indexSchema{name: in}
+	e.indexes = append(e.indexes, is)
+	return is
+}
+
+// adds the attribute an as the kt key of the index named in
+func (e *tableEncoderState) indexKey(in, an, kt, st string) {
+	is := e.index(in)
+	k := &dynamodb.KeySchemaElement{AttributeName: &an, KeyType: &kt}
+	if kt == dynamodb.KeyTypeHash {
+		is.hash = k
+	} else {
+		is.rng = k
+	}
+	e.define(an, st)
+}
+
+// AttributeDefinitions may only describe attributes used in the key of
+// the table or an index, and each only once.
+func (e *tableEncoderState) define(an, st string) {
+	for _, ad := range e.attributeDefinitions {
+		if *ad.AttributeName == an {
+			return
+		}
+	}
 	e.attributeDefinitions = append(e.attributeDefinitions,
 		&dynamodb.AttributeDefinition{
 			AttributeName: &an,
 			AttributeType: &st,
 		})
-	return kt
+}
+
+// fills in the table's CreateTableInput from the encoded state, key
+// schemas list the HASH before the RANGE as dynamoDB requires. A table
+// with no read and no write capacity is created on demand, and neither
+// it nor its indexes are then given a ProvisionedThroughput.
+func (e *tableEncoderState) createTableInput(tn string, w, r int64) *dynamodb.CreateTableInput {
+	ks := make([]*dynamodb.KeySchemaElement, 0, len(e.keySchema))
+	var hash *dynamodb.KeySchemaElement
+	for _, k := range e.keySchema {
+		if *k.KeyType == dynamodb.KeyTypeHash {
+			hash = k
+			ks = append([]*dynamodb.KeySchemaElement{k}, ks...)
+		} else {
+			ks = append(ks, k)
+		}
+	}
+	onDemand := w == 0 && r == 0
+	params := &dynamodb.CreateTableInput{
+		TableName:            &tn,
+		KeySchema:            ks,
+		AttributeDefinitions: e.attributeDefinitions,
+	}
+	if onDemand {
+		bm := dynamodb.BillingModePayPerRequest
+		params.BillingMode = &bm
+	} else {
+		params.ProvisionedThroughput = &dynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  &r,
+			WriteCapacityUnits: &w,
+		}
+	}
+	pt := dynamodb.ProjectionTypeAll
+	for _, is := range e.indexes {
+		in := is.name
+		if is.hash == nil {
+			if is.rng == nil || hash == nil {
+				e.Error(&IndexKeyError{in})
+			}
+			params.LocalSecondaryIndexes = append(params.LocalSecondaryIndexes,
+				&dynamodb.LocalSecondaryIndex{
+					IndexName:  &in,
+					KeySchema:  []*dynamodb.KeySchemaElement{hash, is.rng},
+					Projection: &dynamodb.Projection{ProjectionType: &pt},
+				})
+			continue
+		}
+		iks := []*dynamodb.KeySchemaElement{is.hash}
+		if is.rng != nil {
+			iks = append(iks, is.rng)
+		}
+		gsi := &dynamodb.GlobalSecondaryIndex{
+			IndexName:  &in,
+			KeySchema:  iks,
+			Projection: &dynamodb.Projection{ProjectionType: &pt},
+		}
+		if !onDemand {
+			ir, iw := r, w
+			if is.read != nil {
+				ir = *is.read
+			}
+			if is.write != nil {
+				iw = *is.write
+			}
+			gsi.ProvisionedThroughput = &dynamodb.ProvisionedThroughput{
+				ReadCapacityUnits:  &ir,
+				WriteCapacityUnits: &iw,
+			}
+		}
+		params.GlobalSecondaryIndexes = append(params.GlobalSecondaryIndexes, gsi)
+	}
+	return params
 }
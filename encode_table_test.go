@@ -0,0 +1,310 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+type Account struct {
+	Name    string
+	Created int64  `dynaGo:",RANGE=ByEmail,RANGE=ByCreated"`
+	Id      string `dynaGo:"AccountId,RANGE"`
+	Email   string `dynaGo:",HASH=ByEmail"`
+	Owner   string `dynaGo:",HASH"`
+}
+
+func TestCreateTableInputIndexes(t *testing.T) {
+	ct, err := BuildCreateTableInput(Account{}, 1, 2)
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	defs := make([]string, 0, len(ct.AttributeDefinitions))
+	for _, ad := range ct.AttributeDefinitions {
+		defs = append(defs, *ad.AttributeName+":"+*ad.AttributeType)
+	}
+	sort.Strings(defs)
+	if want := []string{"AccountId:S", "Created:N", "Email:S", "Owner:S"}; !equalStrings(defs, want) {
+		t.Errorf("failed: attribute definitions %v, want %v", defs, want)
+	}
+	if ks := keySchemaString(ct.KeySchema); ks != "Owner:HASH AccountId:RANGE" {
+		t.Errorf("failed: table key schema %s", ks)
+	}
+	if len(ct.GlobalSecondaryIndexes) != 1 || *ct.GlobalSecondaryIndexes[0].IndexName != "ByEmail" {
+		t.Fatalf("failed: global indexes %v", ct.GlobalSecondaryIndexes)
+	}
+	if ks := keySchemaString(ct.GlobalSecondaryIndexes[0].KeySchema); ks != "Email:HASH Created:RANGE" {
+		t.Errorf("failed: ByEmail key schema %s", ks)
+	}
+	if len(ct.LocalSecondaryIndexes) != 1 || *ct.LocalSecondaryIndexes[0].IndexName != "ByCreated" {
+		t.Fatalf("failed: local indexes %v", ct.LocalSecondaryIndexes)
+	}
+	if ks := keySchemaString(ct.LocalSecondaryIndexes[0].KeySchema); ks != "Owner:HASH Created:RANGE" {
+		t.Errorf("failed: ByCreated key schema %s", ks)
+	}
+}
+
+func TestCreateTableInputIndexErrors(t *testing.T) {
+	type BadIndex struct {
+		Id    string   `dynaGo:",HASH"`
+		Peers []string `dynaGo:",HASH=ByPeer"`
+	}
+	if _, err := BuildCreateTableInput(BadIndex{}, 1, 1); err == nil {
+		t.Error("failed: expected error for a slice index key")
+	}
+}
+
+func TestRangeWithoutHash(t *testing.T) {
+	type Reading struct {
+		Sensor string
+		Taken  int64 `dynaGo:",RANGE"`
+	}
+	_, err := BuildCreateTableInput(Reading{}, 1, 1)
+	mk, ok := err.(*MissingKeyError)
+	if !ok || mk.KeyType != dynamodb.KeyTypeHash || mk.RangeField != "Taken" {
+		t.Fatalf("failed: %#v", err)
+	}
+	if !strings.Contains(err.Error(), "found RANGE key Taken but no HASH key") {
+		t.Errorf("failed: message %q", err)
+	}
+}
+
+func TestSparseIndexKey(t *testing.T) {
+	type Job struct {
+		Id       string `dynaGo:",HASH"`
+		Priority int    `dynaGo:",omitempty,HASH=ByPriority"`
+		Failed   bool   `dynaGo:",omitempty"`
+		Attempts int
+	}
+	item := Marshal(Job{Id: "j1"}).Item
+	if _, ok := item["Priority"]; ok {
+		t.Errorf("failed: zero sparse index key written %v", item)
+	}
+	if _, ok := item["Failed"]; ok {
+		t.Errorf("failed: false omitempty bool written %v", item)
+	}
+	if _, ok := item["Attempts"]; !ok {
+		t.Errorf("failed: zero int without omitempty omitted %v", item)
+	}
+	item = Marshal(Job{Id: "j2", Priority: 3, Failed: true}).Item
+	if item["Priority"] == nil || *item["Priority"].N != "3" || item["Failed"] == nil {
+		t.Errorf("failed: non-zero omitempty fields %v", item)
+	}
+	if err := Validate(Job{}); err != nil {
+		t.Errorf("failed: %s", err)
+	}
+
+	type SparseKey struct {
+		Id string `dynaGo:",HASH,omitempty"`
+	}
+	if err := Validate(SparseKey{}); err == nil {
+		t.Error("failed: expected error for an omitempty table key")
+	} else if _, ok := err.(*OmitEmptyKeyError); !ok {
+		t.Errorf("failed: %#v", err)
+	}
+}
+
+func TestCreateTableInputIndexThroughput(t *testing.T) {
+	type Visit struct {
+		Id     string `dynaGo:",HASH"`
+		Origin string `dynaGo:",HASH=ByOrigin,rcu=ByOrigin:10"`
+		Page   string `dynaGo:",HASH=ByPage,wcu=ByPage:7"`
+	}
+	ct, err := BuildCreateTableInput(Visit{}, 1, 2)
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	tp := map[string]string{}
+	for _, gsi := range ct.GlobalSecondaryIndexes {
+		pt := gsi.ProvisionedThroughput
+		tp[*gsi.IndexName] = strconv.FormatInt(*pt.WriteCapacityUnits, 10) + "/" + strconv.FormatInt(*pt.ReadCapacityUnits, 10)
+	}
+	if tp["ByOrigin"] != "1/10" || tp["ByPage"] != "7/2" {
+		t.Errorf("failed: index throughput (write/read) %v", tp)
+	}
+
+	ct, err = BuildCreateTableInput(Visit{}, 0, 0)
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if ct.BillingMode == nil || *ct.BillingMode != dynamodb.BillingModePayPerRequest || ct.ProvisionedThroughput != nil {
+		t.Errorf("failed: on demand table %v %v", ct.BillingMode, ct.ProvisionedThroughput)
+	}
+	for _, gsi := range ct.GlobalSecondaryIndexes {
+		if gsi.ProvisionedThroughput != nil {
+			t.Errorf("failed: on demand index %s has throughput", *gsi.IndexName)
+		}
+	}
+
+	type BadCapacity struct {
+		Id     string `dynaGo:",HASH"`
+		Origin string `dynaGo:",HASH=ByOrigin,rcu=ByOrigin"`
+	}
+	if _, err := BuildCreateTableInput(BadCapacity{}, 1, 1); err == nil {
+		t.Error("failed: expected error for capacity without units")
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for n := range a {
+		if a[n] != b[n] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestEnsureTable(t *testing.T) {
+	ct, _ := BuildCreateTableInput(Account{}, 1, 1)
+	svc := &stubDynamo{tables: map[string]*dynamodb.TableDescription{
+		*ct.TableName: {TableName: ct.TableName, KeySchema: ct.KeySchema},
+	}}
+	if err := EnsureTable(svc, Account{}, 1, 1); err != nil {
+		t.Errorf("failed: existing table %s", err)
+	}
+	if len(svc.created) != 0 {
+		t.Error("failed: existing table created again")
+	}
+	if err := EnsureTable(svc, &usr0, 1, 1); err != nil || len(svc.created) != 1 {
+		t.Errorf("failed: missing table not created %v", err)
+	}
+	svc.tables[*ct.TableName].KeySchema = ct.KeySchema[:1]
+	if err := EnsureTable(svc, Account{}, 1, 1); err == nil {
+		t.Error("failed: expected SchemaMismatchError")
+	}
+}
+
+func TestCreateTableNonKeyAttributes(t *testing.T) {
+	type Gauge struct {
+		Id      string `dynaGo:",HASH"`
+		Enabled bool
+		Level   float64
+		Scale   *float32
+		Meta    interface{}
+	}
+	svc := &stubDynamo{tables: map[string]*dynamodb.TableDescription{}}
+	if err := EnsureTable(svc, Gauge{}, 1, 1); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if len(svc.created) != 1 {
+		t.Fatalf("failed: table not created")
+	}
+	ct := svc.created[0]
+	if ks := keySchemaString(ct.KeySchema); ks != "Id:HASH" {
+		t.Errorf("failed: key schema %s", ks)
+	}
+	if len(ct.AttributeDefinitions) != 1 {
+		t.Errorf("failed: attribute definitions %v", ct.AttributeDefinitions)
+	}
+
+	type BoolKey struct {
+		Id   string `dynaGo:",HASH"`
+		Flag bool   `dynaGo:",RANGE"`
+	}
+	if _, err := BuildCreateTableInput(BoolKey{}, 1, 1); err == nil {
+		t.Error("failed: expected TableKeyCannotBeTypeError for a bool key")
+	} else if _, ok := err.(*TableKeyCannotBeTypeError); !ok {
+		t.Errorf("failed: expected TableKeyCannotBeTypeError, got %T %v", err, err)
+	}
+	type FloatIndex struct {
+		Id    string  `dynaGo:",HASH"`
+		Score float64 `dynaGo:",HASH=ByScore"`
+	}
+	if _, err := BuildCreateTableInput(FloatIndex{}, 1, 1); err == nil {
+		t.Error("failed: expected error for a float index key")
+	}
+}
+
+func TestExplicitKeyType(t *testing.T) {
+	type Blob struct {
+		Digest []byte `dynaGo:",HASH,type=B"`
+		Size   int    `dynaGo:",RANGE,type=N"`
+		Raw    [4]byte
+	}
+	ct, err := BuildCreateTableInput(Blob{}, 1, 1)
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	defs := make([]string, 0, len(ct.AttributeDefinitions))
+	for _, ad := range ct.AttributeDefinitions {
+		defs = append(defs, *ad.AttributeName+":"+*ad.AttributeType)
+	}
+	sort.Strings(defs)
+	if want := []string{"Digest:B", "Size:N"}; !equalStrings(defs, want) {
+		t.Errorf("failed: attribute definitions %v, want %v", defs, want)
+	}
+	if ks := keySchemaString(ct.KeySchema); ks != "Digest:HASH Size:RANGE" {
+		t.Errorf("failed: key schema %s", ks)
+	}
+	b := Blob{Digest: []byte{0xde, 0xad}, Size: 2}
+	km, err := KeyMap(b)
+	if err != nil || string(km["Digest"].B) != "\xde\xad" || *km["Size"].N != "2" {
+		t.Errorf("failed: key map %v %v", km, err)
+	}
+
+	type Mismatch struct {
+		Id string `dynaGo:",HASH,type=N"`
+	}
+	if _, err := BuildCreateTableInput(Mismatch{}, 1, 1); err == nil {
+		t.Error("failed: expected error for a string key of type N")
+	}
+	type Unknown struct {
+		Id string `dynaGo:",HASH,type=X"`
+	}
+	if _, err := BuildCreateTableInput(Unknown{}, 1, 1); err == nil {
+		t.Error("failed: expected error for key type X")
+	}
+	type Implicit struct {
+		Digest []byte `dynaGo:",HASH"`
+	}
+	if _, err := BuildCreateTableInput(Implicit{}, 1, 1); err == nil {
+		t.Error("failed: expected error for a byte slice key without type=B")
+	}
+}
+
+func TestBuildCreateTableInputWithKeys(t *testing.T) {
+	type Visit struct {
+		Id      string `dynaGo:",HASH"`
+		Page    string `dynaGo:"page,HASH=ByPage"`
+		At      int64
+		Tags    []string
+		Session *string
+	}
+	ct, err := BuildCreateTableInputWithKeys(Visit{}, "Page", "At", 2, 3)
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if ks := keySchemaString(ct.KeySchema); ks != "page:HASH At:RANGE" {
+		t.Errorf("failed: key schema %s", ks)
+	}
+	defs := make([]string, 0, len(ct.AttributeDefinitions))
+	for _, ad := range ct.AttributeDefinitions {
+		defs = append(defs, *ad.AttributeName+":"+*ad.AttributeType)
+	}
+	if want := []string{"page:S", "At:N"}; !equalStrings(defs, want) {
+		t.Errorf("failed: attribute definitions %v, want %v", defs, want)
+	}
+	if ct.GlobalSecondaryIndexes != nil || *ct.ProvisionedThroughput.ReadCapacityUnits != 3 {
+		t.Errorf("failed: indexes %v throughput %v", ct.GlobalSecondaryIndexes, ct.ProvisionedThroughput)
+	}
+
+	if ct, err := BuildCreateTableInputWithKeys(&Visit{}, "Session", "", 0, 0); err != nil || keySchemaString(ct.KeySchema) != "Session:HASH" {
+		t.Errorf("failed: hash only table %v %v", ct, err)
+	}
+	if _, err := BuildCreateTableInputWithKeys(Visit{}, "Nope", "", 1, 1); err == nil {
+		t.Error("failed: expected error for unknown field")
+	}
+	if _, err := BuildCreateTableInputWithKeys(Visit{}, "Id", "Tags", 1, 1); err == nil {
+		t.Error("failed: expected error for non-scalar key")
+	}
+}
@@ -8,7 +8,10 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math"
+	"math/big"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -304,6 +307,211 @@ func tryGetValue(t *testing.T, i interface{}, v interface{}, k ...interface{}) {
 
 }
 
+type Ledger struct {
+	Id      string `dynaGo:",HASH"`
+	Balance *big.Int
+	Rate    *big.Float
+	Total   big.Int
+}
+
+func TestBigNumberRoundTrip(t *testing.T) {
+	bal, _ := new(big.Int).SetString("-12345678901234567890123456789012345678", 10)
+	tot, _ := new(big.Int).SetString("98765432109876543210000000000000000000000000", 10)
+	l := Ledger{Id: "acct", Balance: bal, Rate: big.NewFloat(0.0425)}
+	l.Total.Set(tot)
+
+	pi := Marshal(&l)
+	if n := *pi.Item["Balance"].N; n != bal.String() {
+		t.Errorf("failed: Balance encoded as %s", n)
+	}
+	if n := *pi.Item["Rate"].N; n != "0.0425" {
+		t.Errorf("failed: Rate encoded as %s", n)
+	}
+	var out Ledger
+	if err := Unmarshal(pi.Item, &out); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if out.Balance.Cmp(bal) != 0 || out.Total.Cmp(tot) != 0 {
+		t.Errorf("failed: decoded %s %s, want %s %s", out.Balance, &out.Total, bal, tot)
+	}
+	if f, _ := out.Rate.Float64(); f != 0.0425 {
+		t.Errorf("failed: decoded Rate %s", out.Rate)
+	}
+}
+
+func TestBigNumberPrecision(t *testing.T) {
+	long, _ := new(big.Int).SetString("123456789012345678901234567890123456789", 10)
+	defer func() {
+		if _, ok := recover().(*NumberPrecisionError); !ok {
+			t.Error("failed: expected NumberPrecisionError for 39 digits")
+		}
+	}()
+	Marshal(Ledger{Id: "acct", Balance: long})
+}
+
+func TestStorableNumber(t *testing.T) {
+	for _, tt := range []struct {
+		n    string
+		want bool
+	}{
+		{"0", true},
+		{"-0.000", true},
+		{"12345678901234567890123456789012345678", true},
+		{"123456789012345678901234567890123456789", false},
+		{"1234567890123456789012345678901234567800000", true},
+		{"0.00012345678901234567890123456789012345678", true},
+		{"1.2345678901234567890123456789012345678e-2", true},
+		{"9.9999999999999999999999999999999999999e+125", true},
+		{"1e+126", false},
+		{"1e-130", true},
+		{"1e-131", false},
+		{"NaN", false},
+		{"+Inf", false},
+		{"-Inf", false},
+		{".", false},
+		{"1.2.3", false},
+		{"e5", false},
+	} {
+		if got := storableNumber(tt.n); got != tt.want {
+			t.Errorf("storableNumber(%q) = %v", tt.n, got)
+		}
+	}
+}
+
+type Event struct {
+	Stream  string `dynaGo:",HASH"`
+	Seq     int64  `dynaGo:",RANGE"`
+	SortKey string `dynaGo:",readonly"`
+	Body    string
+}
+
+func TestReadOnlyField(t *testing.T) {
+	ev := Event{Stream: "s1", Seq: 7, SortKey: "s1#0000007", Body: "hello"}
+	item := Marshal(ev).Item
+	if _, ok := item["SortKey"]; ok {
+		t.Error("failed: readonly field was marshaled")
+	}
+	if in, err := Update(ev).Input(); err != nil || len(in.ExpressionAttributeNames) != 1 {
+		t.Errorf("failed: update of readonly field %v %v", in, err)
+	}
+	if _, err := Update(ev).FieldMask("SortKey").Input(); err == nil {
+		t.Error("failed: expected error masking a readonly field")
+	}
+	ct, err := BuildCreateTableInput(ev, 1, 1)
+	if err != nil || len(ct.AttributeDefinitions) != 2 {
+		t.Errorf("failed: table definitions %v %v", ct, err)
+	}
+	item["SortKey"] = &dynamodb.AttributeValue{S: aws.String("s1#0000007")}
+	var out Event
+	if err := Unmarshal(item, &out); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if out != ev {
+		t.Errorf("failed: decoded %+v, want %+v", out, ev)
+	}
+}
+
+type Clash struct {
+	Id    string `dynaGo:",HASH"`
+	Email string
+	Alt   string `dynaGo:"Email"`
+}
+
+func TestDuplicateAttributeName(t *testing.T) {
+	err := Validate(Clash{})
+	dae, ok := err.(*DuplicateAttributeError)
+	if !ok {
+		t.Fatalf("failed: expected DuplicateAttributeError, got %v", err)
+	}
+	if dae.FieldName != "Email" || dae.OtherField != "Alt" || dae.AttributeName != "Email" {
+		t.Errorf("failed: error names %s", dae)
+	}
+	if _, err := std.marshal(Clash{Id: "c", Email: "a@b", Alt: "c@d"}); err == nil {
+		t.Error("failed: expected Marshal to reject the collision")
+	}
+	if err := Validate(&usr0); err != nil {
+		t.Errorf("failed: %s", err)
+	}
+	if err := Validate(struct{ Name string }{}); err == nil {
+		t.Error("failed: expected MissingKeyError")
+	}
+}
+
+func TestAttributeNameLimits(t *testing.T) {
+	type Note struct {
+		Id   string `dynaGo:",HASH"`
+		Body string
+	}
+	type Wide struct {
+		Id   string `dynaGo:",HASH"`
+		Body string
+	}
+	long := strings.Repeat("b", maxKeyAttrNameLen+1)
+	enc := NewEncoder()
+	enc.SetNameTransformer(func(n string) string {
+		if n == "Body" {
+			return ""
+		}
+		return n
+	})
+	_, err := enc.marshal(Note{Id: "n1", Body: "hi"})
+	ane, ok := err.(*AttributeNameError)
+	if !ok || ane.FieldName != "Body" || ane.AttributeName != "" {
+		t.Errorf("failed: expected AttributeNameError for an empty name, got %v", err)
+	}
+	enc.SetNameTransformer(func(n string) string { return long })
+	_, err = enc.marshal(Wide{Id: "w1"})
+	ane, ok = err.(*AttributeNameError)
+	if !ok || ane.FieldName != "Id" {
+		t.Errorf("failed: expected AttributeNameError for an over-long key name, got %v", err)
+	}
+	type Dashed struct {
+		Id   string `dynaGo:",HASH"`
+		Body string `dynaGo:"body-text"`
+	}
+	err = Validate(Dashed{})
+	if ane, ok := err.(*AttributeNameError); !ok || ane.AttributeName != "body-text" {
+		t.Errorf("failed: expected AttributeNameError flagging body-text, got %v", err)
+	}
+	if _, err := std.marshal(Dashed{Id: "d1", Body: "hi"}); err != nil {
+		t.Errorf("failed: names needing aliasing should still marshal, got %s", err)
+	}
+}
+
+func TestMarshalDynamic(t *testing.T) {
+	pi, err := MarshalDynamic("Records", map[string]interface{}{
+		"Id":     "r1",
+		"Count":  3,
+		"Ratio":  0.25,
+		"Active": true,
+		"Gone":   nil,
+		"Meta": map[string]interface{}{
+			"source": "import",
+			"nested": map[string]interface{}{"depth": 2},
+		},
+		"Mixed": []interface{}{"a", 1, false},
+	})
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	item := pi.Item
+	if *pi.TableName != "Records" || len(item) != 6 {
+		t.Errorf("failed: %s %v", *pi.TableName, item)
+	}
+	if *item["Id"].S != "r1" || *item["Count"].N != "3" || *item["Ratio"].N != "0.25" || !*item["Active"].BOOL {
+		t.Errorf("failed: scalars %v", item)
+	}
+	if *item["Meta"].M["source"].S != "import" || *item["Meta"].M["nested"].M["depth"].N != "2" {
+		t.Errorf("failed: nested maps %v", item["Meta"])
+	}
+	if l := item["Mixed"].L; len(l) != 3 || *l[0].S != "a" || *l[1].N != "1" || *l[2].BOOL {
+		t.Errorf("failed: list %v", item["Mixed"])
+	}
+	if _, err := MarshalDynamic("Records", map[string]interface{}{"Bad": make(chan int)}); err == nil {
+		t.Error("failed: expected error for a chan value")
+	}
+}
+
 type Tag struct {
 	Name     string `dynaGo:",HASH"`
 	Id       string `dynaGo:"TagId"`
@@ -337,3 +545,132 @@ type Message struct {
 	Origin    map[string]string
 	Body      string
 }
+
+type Metrics struct {
+	Id       string `dynaGo:",HASH"`
+	Region   string
+	Host     string
+	Requests int64
+	Errors   int64
+	Bytes    int64
+	Latency  int64
+	Retries  int
+	Shards   int
+	Replicas int
+	Version  int
+}
+
+func BenchmarkMarshalNumbers(b *testing.B) {
+	m := Metrics{"m1", "us-east-1", "web-12", 982341, 1201, 1 << 40, 123456, 17, 64, 3, 20160412}
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		Marshal(m)
+	}
+}
+
+func TestMarshalNonFinite(t *testing.T) {
+	for _, f := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		_, err := MarshalValue(f)
+		if _, ok := err.(*NonFiniteNumberError); !ok {
+			t.Errorf("failed: expected NonFiniteNumberError for %v, got %v", f, err)
+		}
+	}
+	if _, err := MarshalValue(float32(math.Inf(1))); err == nil {
+		t.Error("failed: expected error for an infinite float32")
+	}
+	_, err := MarshalValue(new(big.Float).SetInf(true))
+	if _, ok := err.(*NonFiniteNumberError); !ok {
+		t.Errorf("failed: expected NonFiniteNumberError for an infinite big.Float, got %v", err)
+	}
+	if av, err := MarshalValue(1.5); err != nil || *av.N != "1.5" {
+		t.Errorf("failed: finite float %v %v", av, err)
+	}
+}
+
+func TestMarshalValue(t *testing.T) {
+	av, err := MarshalValue("bob")
+	if err != nil || *av.S != "bob" {
+		t.Errorf("failed: string %v %v", av, err)
+	}
+	if av, err = MarshalValue(int8(-7)); err != nil || *av.N != "-7" {
+		t.Errorf("failed: int %v %v", av, err)
+	}
+	if av, err = MarshalValue(false); err != nil || av.BOOL == nil || *av.BOOL {
+		t.Errorf("failed: bool %v %v", av, err)
+	}
+	if av, err = MarshalValue([]string{"a", "b"}); err != nil || len(av.SS) != 2 {
+		t.Errorf("failed: slice %v %v", av, err)
+	}
+	// a keyed struct is its key, a keyless one a map
+	if av, err = MarshalValue(usr0); err != nil || *av.S != usr0.Id {
+		t.Errorf("failed: keyed struct %v %v", av, err)
+	}
+	if av, err = MarshalValue(Money{100, "USD"}); err != nil || *av.M["cur"].S != "USD" {
+		t.Errorf("failed: keyless struct %v %v", av, err)
+	}
+	if _, err = MarshalValue([]int{}); err == nil {
+		t.Error("failed: expected EmptyValueError for an empty slice")
+	}
+	if _, err = MarshalValue(nil); err == nil {
+		t.Error("failed: expected error for nil")
+	}
+}
+func TestPaddedStringInt(t *testing.T) {
+	type Entry struct {
+		Board string `dynaGo:",HASH"`
+		Rank  int    `dynaGo:",RANGE,pad=10,string"`
+		Score int64  `dynaGo:",string"`
+	}
+	e := Entry{Board: "b1", Rank: 42, Score: -7}
+	item := Marshal(e).Item
+	if item["Rank"].S == nil || *item["Rank"].S != "0000000042" {
+		t.Errorf("failed: padded rank %v", item["Rank"])
+	}
+	if item["Score"].S == nil || *item["Score"].S != "-7" {
+		t.Errorf("failed: string score %v", item["Score"])
+	}
+	var out Entry
+	if err := Unmarshal(item, &out); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if out != e {
+		t.Errorf("failed: decoded %+v, want %+v", out, e)
+	}
+
+	km, err := KeyMap(e)
+	if err != nil || km["Rank"].S == nil || *km["Rank"].S != "0000000042" {
+		t.Errorf("failed: key %v %v", km, err)
+	}
+	ct, err := BuildCreateTableInput(e, 1, 1)
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	for _, ad := range ct.AttributeDefinitions {
+		if *ad.AttributeName == "Rank" && *ad.AttributeType != dynamodb.ScalarAttributeTypeS {
+			t.Errorf("failed: Rank defined as %s", *ad.AttributeType)
+		}
+	}
+	qi, err := Query(e).Range(">", 7).Input()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	for _, av := range qi.ExpressionAttributeValues {
+		if av.S != nil && *av.S == "0000000007" {
+			return
+		}
+	}
+	t.Errorf("failed: query values %v", qi.ExpressionAttributeValues)
+}
+
+func TestPaddedStringIntErrors(t *testing.T) {
+	type Wide struct {
+		Id   string `dynaGo:",HASH"`
+		Rank int    `dynaGo:",pad=2,string"`
+	}
+	if _, err := NewEncoder().marshal(Wide{"w", 100}); err == nil {
+		t.Error("failed: expected error for a value wider than its padding")
+	}
+	if _, err := NewEncoder().marshal(Wide{"w", -1}); err == nil {
+		t.Error("failed: expected error for a negative padded value")
+	}
+}
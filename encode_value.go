@@ -6,16 +6,56 @@ package dynaGo
 
 import (
 	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
 
 type valueEncoderFunc func(e *valueEncoderState, n string, v reflect.Value) string
 
+var (
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigFloatType = reflect.TypeOf(big.Float{})
+	timeType     = reflect.TypeOf(time.Time{})
+	rawMapType   = reflect.TypeOf(map[string]*dynamodb.AttributeValue(nil))
+	rawType      = reflect.TypeOf((*dynamodb.AttributeValue)(nil))
+	durationType = reflect.TypeOf(time.Duration(0))
+	ipType       = reflect.TypeOf(net.IP(nil))
+	ipNetType    = reflect.TypeOf(net.IPNet{})
+	urlType      = reflect.TypeOf(url.URL{})
+)
+
 func valueEncoder(t reflect.Type) valueEncoderFunc {
+	// types with a meaning beyond their kind come first
+	switch t {
+	case bigIntType:
+		return bigIntValueEncoder
+	case bigFloatType:
+		return bigFloatValueEncoder
+	case timeType:
+		return timeValueEncoder
+	case rawMapType:
+		return rawMapValueEncoder
+	case rawType:
+		return rawValueEncoder
+	case ipType:
+		return ipValueEncoder
+	case ipNetType:
+		return ipNetValueEncoder
+	case urlType:
+		return urlValueEncoder
+	}
+	if isSQLNull(t) {
+		return sqlNullValueEncoder
+	}
 	switch t.Kind() {
 	case reflect.Slice:
 		return sliceValueEncoder
@@ -25,15 +65,100 @@ func valueEncoder(t reflect.Type) valueEncoderFunc {
 		return stringValueEncoder
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		return intValueEncoder
+	case reflect.Float32, reflect.Float64:
+		return floatValueEncoder
+	case reflect.Bool:
+		return boolValueEncoder
 	case reflect.Ptr:
 		return newPtrValueEncoder(t)
 	case reflect.Map:
 		return newMapValueEncoder(t)
+	case reflect.Interface:
+		return interfaceValueEncoder
+	case reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return byteArrayValueEncoder
+		}
+		return valueUnsupportedTypeEncoder
 	default:
 		return valueUnsupportedTypeEncoder
 	}
 }
 
+// the valueEncoderFunc for a struct field, which may depend on the
+// options of its tag as well as its type.
+func fieldValueEncoder(sf reflect.StructField) valueEncoderFunc {
+	_, o := parseTag(sf.Tag.Get("dynaGo"))
+	enc := valueEncoder(sf.Type)
+	if o.Contains("list") && sf.Type.Kind() == reflect.Slice {
+		enc = newListValueEncoder(sf.Type)
+	}
+	if pad, ok := stringInt(sf); ok {
+		enc = (&stringIntValueEncoder{pad}).encode
+	}
+	if t := sf.Type; (t == ipType || t.Kind() == reflect.Ptr && t.Elem() == ipType) &&
+		o.Contains("type="+dynamodb.ScalarAttributeTypeB) {
+		enc = sliceValueEncoder
+		if t.Kind() == reflect.Ptr {
+			enc = (&ptrValueEncoder{enc}).encode
+		}
+	}
+	if isDurationString(sf) {
+		enc = durationStringValueEncoder
+		if sf.Type.Kind() == reflect.Ptr {
+			enc = (&ptrValueEncoder{enc}).encode
+		}
+	}
+	if isTTL(sf) {
+		enc = ttlValueEncoder
+		if sf.Type.Kind() == reflect.Ptr {
+			enc = (&ptrValueEncoder{enc}).encode
+		}
+	}
+	if isBoolSetMap(sf) {
+		enc = boolSetMapValueEncoder
+	}
+	if o.Contains("null") && isSQLNull(sf.Type) {
+		enc = sqlNullOrNULLValueEncoder
+	}
+	if o.Contains("omitempty") {
+		return omitEmptyEncoder(enc)
+	}
+	return enc
+}
+
+// skips the zero values enc would otherwise write
+func omitEmptyEncoder(enc valueEncoderFunc) valueEncoderFunc {
+	return func(e *valueEncoderState, n string, v reflect.Value) string {
+		if isEmptyValue(v) {
+			return ""
+		}
+		return enc(e, n, v)
+	}
+}
+
+// as encoding/json's omitempty, with comparable structs (time.Time)
+// empty at their zero value
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	case reflect.Struct:
+		return v.Type().Comparable() && v.Interface() == reflect.Zero(v.Type()).Interface()
+	}
+	return false
+}
+
 func valueUnsupportedTypeEncoder(e *valueEncoderState, n string, v reflect.Value) string {
 	e.Error(&UnsupportedKindError{v.Type().Kind()})
 	return ""
@@ -41,34 +166,408 @@ func valueUnsupportedTypeEncoder(e *valueEncoderState, n string, v reflect.Value
 
 type valueEncoderState struct {
 	item map[string]*dynamodb.AttributeValue
+	// names the attributes of untagged fields, at every level
+	namer func(string) string
+}
+
+// a state for the attributes nested within one of e's (the fields of a
+// struct map, elements of a list), naming fields as e does. e may be nil.
+func (e *valueEncoderState) nested() *valueEncoderState {
+	s := &valueEncoderState{item: make(map[string]*dynamodb.AttributeValue)}
+	if e != nil {
+		s.namer = e.namer
+	}
+	return s
 }
 
 func (e *valueEncoderState) Error(err error) {
 	panic(err)
 }
 
+// An attribute allocated along with the string it points to, so storing
+// a scalar costs one allocation where a local string whose address is
+// taken costs two (its header escapes alongside the AttributeValue).
+type scalarAttribute struct {
+	av  dynamodb.AttributeValue
+	str string
+}
+
+func numberAttribute(str string) *dynamodb.AttributeValue {
+	sa := &scalarAttribute{str: str}
+	sa.av.N = &sa.str
+	return &sa.av
+}
+
+func stringAttribute(str string) *dynamodb.AttributeValue {
+	sa := &scalarAttribute{str: str}
+	sa.av.S = &sa.str
+	return &sa.av
+}
+
 func intValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
 	str := strconv.FormatInt(v.Int(), 10)
 	if e != nil {
-		e.item[n] = &dynamodb.AttributeValue{N: &str}
+		e.item[n] = numberAttribute(str)
+	}
+	return str
+}
+
+// Integers tagged `dynaGo:",string"` are stored as an S of their digits
+// rather than an N, and with pad=N (`dynaGo:",pad=10,string"`) zero
+// padded to N digits, so that they sort as numbers when dynamoDB sorts
+// them as strings (42 is stored as "0000000042"). A padded value must
+// be positive and fit the padding, or it panics with a PaddedIntError.
+type stringIntValueEncoder struct {
+	pad int
+}
+
+func (se *stringIntValueEncoder) encode(e *valueEncoderState, n string, v reflect.Value) string {
+	str := formatStringInt(v.Int(), se.pad)
+	if e != nil {
+		e.item[n] = stringAttribute(str)
 	}
 	return str
 }
+
+// reports whether the integer field sf is stored as a string, and the
+// width of its padding (0 for none)
+func stringInt(sf reflect.StructField) (int, bool) {
+	if sf.Type == durationType {
+		return 0, false
+	}
+	switch sf.Type.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+	default:
+		return 0, false
+	}
+	_, o := parseTag(sf.Tag.Get("dynaGo"))
+	if !o.Contains("string") {
+		return 0, false
+	}
+	pad := 0
+	if ps := o.Values("pad"); len(ps) > 0 {
+		var err error
+		if pad, err = strconv.Atoi(ps[0]); err != nil || pad <= 0 {
+			panic(&TagOptionError{sf.Name, "pad=" + ps[0]})
+		}
+	}
+	return pad, true
+}
+
+// A time.Duration is an int64 of nanoseconds, and stored as such in an
+// N. With the option string (`dynaGo:",string"`) it is stored instead as
+// an S in the form of Duration.String ("1h30m0s"), read back with
+// time.ParseDuration. Such a field can't be a key of the table.
+func durationStringValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
+	str := time.Duration(v.Int()).String()
+	if e != nil {
+		e.item[n] = stringAttribute(str)
+	}
+	return str
+}
+
+// reports whether sf is a time.Duration (or pointer to one) stored as a
+// string, see durationStringValueEncoder
+func isDurationString(sf reflect.StructField) bool {
+	t := sf.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	_, o := parseTag(sf.Tag.Get("dynaGo"))
+	return t == durationType && o.Contains("string")
+}
+
+func formatStringInt(i int64, pad int) string {
+	str := strconv.FormatInt(i, 10)
+	if pad == 0 {
+		return str
+	}
+	if i < 0 || len(str) > pad {
+		panic(&PaddedIntError{i, pad})
+	}
+	return strings.Repeat("0", pad-len(str)) + str
+}
+
+// NaN and the infinities have no N to be stored as, and are reported as
+// a NonFiniteNumberError.
+func floatValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
+	f := v.Float()
+	str := strconv.FormatFloat(f, 'g', -1, v.Type().Bits())
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		e.Error(&NonFiniteNumberError{str})
+	}
+	return numberValueEncoder(e, n, str)
+}
+func boolValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
+	b := v.Bool()
+	if e != nil {
+		e.item[n] = &dynamodb.AttributeValue{BOOL: &b}
+	}
+	return strconv.FormatBool(b)
+}
+
+// fixed size byte arrays ([16]byte and the like) are binary, as []byte is
+func byteArrayValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
+	b := make([]byte, v.Len())
+	reflect.Copy(reflect.ValueOf(b), v)
+	if e != nil {
+		e.item[n] = &dynamodb.AttributeValue{B: b}
+	}
+	return "[" + fmt.Sprintf("% x", b) + "]"
+}
+
+// the value held by an interface is encoded according to its own type,
+// a nil interface is omitted.
+func interfaceValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
+	if v.IsNil() {
+		return ""
+	}
+	ev := v.Elem()
+	return valueEncoder(ev.Type())(e, n, ev)
+}
+
+// math/big numbers are stored as N, so they're limited to the 38 digits
+// of precision (and magnitude below 1E+126) DynamoDB supports. Values
+// which can't be stored exactly are reported as a NumberPrecisionError,
+// and an infinite big.Float as a NonFiniteNumberError.
+func bigIntValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
+	var bi *big.Int
+	if v.CanAddr() {
+		bi = v.Addr().Interface().(*big.Int)
+	} else {
+		c := v.Interface().(big.Int)
+		bi = &c
+	}
+	return numberValueEncoder(e, n, bi.String())
+}
+func bigFloatValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
+	var bf *big.Float
+	if v.CanAddr() {
+		bf = v.Addr().Interface().(*big.Float)
+	} else {
+		c := v.Interface().(big.Float)
+		bf = &c
+	}
+	if bf.IsInf() {
+		e.Error(&NonFiniteNumberError{bf.Text('g', -1)})
+	}
+	// String() rounds to 10 digits, -1 is the shortest exact form
+	return numberValueEncoder(e, n, bf.Text('g', -1))
+}
+func numberValueEncoder(e *valueEncoderState, n string, str string) string {
+	if !storableNumber(str) {
+		e.Error(&NumberPrecisionError{str})
+	}
+	if e != nil {
+		e.item[n] = numberAttribute(str)
+	}
+	return str
+}
+
+// checks a decimal string (optionally signed, with a fraction and an
+// exponent) has at most 38 significant digits and a magnitude DynamoDB
+// can represent: 1E-130 up to, but not including, 1E+126.
+func storableNumber(str string) bool {
+	str = strings.TrimLeft(str, "+-")
+	exp := 0
+	if i := strings.IndexAny(str, "eE"); i != -1 {
+		x, err := strconv.Atoi(str[i+1:])
+		if err != nil {
+			return false
+		}
+		str, exp = str[:i], x
+	}
+	if !decimalDigits(str) {
+		return false
+	}
+	// position the exponent just after the first significant digit
+	if i := strings.Index(str, "."); i != -1 {
+		exp += i
+		str = str[:i] + str[i+1:]
+	} else {
+		exp += len(str)
+	}
+	lead := len(str)
+	str = strings.TrimLeft(str, "0")
+	exp -= lead - len(str)
+	str = strings.TrimRight(str, "0")
+	if str == "" {
+		return true
+	}
+	return len(str) <= 38 && exp-1 < 126 && exp-1 >= -130
+}
+
+// reports whether str is digits, with at least one, around at most one
+// decimal point
+func decimalDigits(str string) bool {
+	digits, point := 0, false
+	for _, c := range str {
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == '.' && !point:
+			point = true
+		default:
+			return false
+		}
+	}
+	return digits > 0
+}
+
+// times are stored in UTC as RFC3339 strings (with nanoseconds when
+// present) so they sort chronologically, the zero time is omitted like
+// an empty string. A []time.Time becomes a string set.
+func timeValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
+	t := v.Interface().(time.Time)
+	str := t.UTC().Format(time.RFC3339Nano)
+	if !t.IsZero() && e != nil {
+		e.item[n] = stringAttribute(str)
+	}
+	return str
+}
+
+// A net.IP is stored as an S of its textual form ("10.0.0.1", "::1"), or
+// with the option type=B as a B of its bytes. A net.IPNet is stored as an
+// S in CIDR notation ("10.0.0.0/8") and a url.URL as an S of its String.
+// Nil addresses and empty URLs are omitted.
+func ipValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
+	if v.Len() == 0 {
+		return ""
+	}
+	return stringValueEncoder(e, n, reflect.ValueOf(v.Interface().(net.IP).String()))
+}
+
+func ipNetValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
+	ipn := v.Interface().(net.IPNet)
+	if len(ipn.IP) == 0 {
+		return ""
+	}
+	return stringValueEncoder(e, n, reflect.ValueOf(ipn.String()))
+}
+
+func urlValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
+	u := v.Interface().(url.URL)
+	return stringValueEncoder(e, n, reflect.ValueOf(u.String()))
+}
+
+// A map[string]*dynamodb.AttributeValue is stored as an M of its
+// attributes as they are, for opaque nested data dynaGo shouldn't
+// interpret. A nil map is omitted.
+func rawMapValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
+	if v.IsNil() {
+		return ""
+	}
+	m := v.Interface().(map[string]*dynamodb.AttributeValue)
+	av := &dynamodb.AttributeValue{M: make(map[string]*dynamodb.AttributeValue, len(m))}
+	for k, a := range m {
+		av.M[k] = a
+	}
+	if e != nil {
+		e.item[n] = av
+	}
+	return av.String()
+}
+
+// A *dynamodb.AttributeValue field is written as it is, whatever type of
+// attribute it holds, a nil one being left out.
+func rawValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
+	if v.IsNil() {
+		return ""
+	}
+	av := v.Interface().(*dynamodb.AttributeValue)
+	if e != nil {
+		e.item[n] = av
+	}
+	return av.String()
+}
+
 func stringValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
 	str := v.String()
 	if str != "" && e != nil {
-		e.item[n] = &dynamodb.AttributeValue{S: &str}
+		e.item[n] = stringAttribute(str)
 	}
 	return str
 }
+
+// A nested struct with a HASH key is a reference to another item, and
+// is stored as that key. One without (a value object such as an amount
+// and its currency) is stored whole, as an M of its fields.
 func structValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
+	if !hasPartitionKey(v.Type()) {
+		return structMapValueEncoder(e, n, v)
+	}
 	i := getPartitionKey(v.Type())
 	str := v.FieldByIndex(i).String()
 	if e != nil {
-		e.item[n] = &dynamodb.AttributeValue{S: &str}
+		e.item[n] = stringAttribute(str)
 	}
 	return str
 }
+
+// reports whether t is one of the sql.Null types (NullString, NullInt64,
+// ...), a value and whether it is Valid
+func isSQLNull(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t.PkgPath() != "database/sql" || !strings.HasPrefix(t.Name(), "Null") {
+		return false
+	}
+	return t.NumField() == 2 && t.Field(1).Name == "Valid" && t.Field(1).Type.Kind() == reflect.Bool
+}
+
+// A sql.Null value is stored as its value when Valid, and left out when
+// not, or stored as NULL with the option null, see
+// sqlNullOrNULLValueEncoder.
+func sqlNullValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
+	if !v.Field(1).Bool() {
+		return ""
+	}
+	return valueEncoder(v.Field(0).Type())(e, n, v.Field(0))
+}
+
+// the sqlNullValueEncoder of a field tagged `dynaGo:",null"`, storing a
+// value which isn't Valid as NULL
+func sqlNullOrNULLValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
+	if v.Field(1).Bool() {
+		return sqlNullValueEncoder(e, n, v)
+	}
+	if e != nil {
+		null := true
+		e.item[n] = &dynamodb.AttributeValue{NULL: &null}
+	}
+	return "NULL"
+}
+
+// reports whether values of type t (or the type t points to) are stored
+// as an M, being structs without a key or special meaning. Slices of them
+// are stored as an L of M, sets of maps being impossible.
+func storedAsMap(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case bigIntType, bigFloatType, timeType, ipNetType, urlType:
+		return false
+	}
+	return t.Kind() == reflect.Struct && !isSQLNull(t) && !hasPartitionKey(t)
+}
+
+func structMapValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
+	t := v.Type()
+	ms := e.nested()
+	checkAttrNames(t, ms.namer)
+	arrEle := make([]string, 0, t.NumField())
+	for _, sf := range attrFields(t) {
+		fv, ok := fieldByIndex(v, sf.Index)
+		if !ok || isReadOnly(sf) {
+			continue
+		}
+		an := attrName(sf, ms.namer)
+		arrEle = append(arrEle, an+":"+fieldValueEncoder(sf)(ms, an, fv))
+	}
+	if e != nil {
+		e.item[n] = &dynamodb.AttributeValue{M: ms.item}
+	}
+	return "{" + strings.Join(arrEle, ",") + "}"
+}
 func sliceValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
 	l, et := v.Len(), v.Type().Elem()
 	// if slice has no lenght, add no AttributeValue
@@ -80,10 +579,18 @@ func sliceValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
 	arrEle := make([]string, l)
 	enc := valueEncoder(et)
 
+	// elements of mixed types can't share a set, nor can maps or bools,
+	// there being no set of either
+	if et.Kind() == reflect.Interface || et.Kind() == reflect.Map || storedAsMap(et) || isBoolElem(et) {
+		return newListValueEncoder(v.Type())(e, n, v)
+	}
 	// special case is []byte, which will look like []int8
 	if et.Kind() == reflect.Uint8 {
-		b := v.Interface().([]byte)
-		e.item[n] = &dynamodb.AttributeValue{B: b}
+		// Bytes, unlike a type assertion, accepts named []byte types
+		b := v.Bytes()
+		if e != nil {
+			e.item[n] = &dynamodb.AttributeValue{B: b}
+		}
 		return "[" + fmt.Sprintf("% x", b) + "]"
 	}
 
@@ -93,7 +600,8 @@ func sliceValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
 	}
 	if e != nil {
 		switch et.Kind() {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Float32, reflect.Float64:
 			e.item[n] = &dynamodb.AttributeValue{NS: arrPtr}
 		default:
 			e.item[n] = &dynamodb.AttributeValue{SS: arrPtr}
@@ -102,25 +610,80 @@ func sliceValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
 	return "[" + strings.Join(arrEle, ",") + "]"
 }
 
+// reports whether t is a bool, or a pointer to one
+func isBoolElem(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Bool
+}
+
+// Slices are stored as sets by default, which loses their order and any
+// duplicates. Tagging the field `dynaGo:",list"` stores it as an L of
+// its elements instead. Elements which encode to nothing (such as empty
+// strings) are kept in place as NULL. Unlike a set a list may be empty,
+// so an empty slice is written as an empty L while a nil slice is left
+// out, and the two decode as they were. Slices of bools, of maps, of
+// structs stored as maps and of interfaces have no set to be stored as,
+// and are always stored as lists.
+type listValueEncoder struct {
+	elemEnc valueEncoderFunc
+}
+
+func (le *listValueEncoder) encode(e *valueEncoderState, n string, v reflect.Value) string {
+	l := v.Len()
+	if l == 0 {
+		if v.Kind() == reflect.Slice && !v.IsNil() && e != nil {
+			e.item[n] = &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{}}
+		}
+		return "[]"
+	}
+	arrEle := make([]string, l)
+	list := make([]*dynamodb.AttributeValue, l)
+	null := true
+	for i := 0; i < l; i++ {
+		es := e.nested()
+		arrEle[i] = le.elemEnc(es, n, v.Index(i))
+		if av, ok := es.item[n]; ok {
+			list[i] = av
+		} else {
+			list[i] = &dynamodb.AttributeValue{NULL: &null}
+		}
+	}
+	if e != nil {
+		e.item[n] = &dynamodb.AttributeValue{L: list}
+	}
+	return "[" + strings.Join(arrEle, ",") + "]"
+}
+
+func newListValueEncoder(t reflect.Type) valueEncoderFunc {
+	enc := &listValueEncoder{valueEncoder(t.Elem())}
+	return enc.encode
+}
+
 type mapValueEncoder struct {
 	elemEnc valueEncoderFunc
 }
 
-// this won't work as expected for map[string]interface{}
-// the method expects a uniform map value type
+// Maps with string keys are stored as an M of their values, each encoded
+// by the encoder of the map's value type (so a map[string][]int holds
+// sets, and a map[string]Struct holds an M of each struct). The string
+// returned lists the entries in key order.
 func (me *mapValueEncoder) encode(e *valueEncoderState, n string, v reflect.Value) string {
 	if v.IsNil() {
 		return ""
 	}
 	ks := v.MapKeys()
+	sort.Slice(ks, func(a, b int) bool { return ks[a].String() < ks[b].String() })
 	arrEle := make([]string, 0, len(ks))
-	ms := &valueEncoderState{make(map[string]*dynamodb.AttributeValue)}
+	ms := e.nested()
 	for _, k := range ks {
 		kn, kv := k.String(), v.MapIndex(k)
-		arrEle = append(arrEle, kn+":"+kv.String())
-		me.elemEnc(ms, kn, kv)
+		arrEle = append(arrEle, kn+":"+me.elemEnc(ms, kn, kv))
+	}
+	if e != nil {
+		e.item[n] = &dynamodb.AttributeValue{M: ms.item}
 	}
-	e.item[n] = &dynamodb.AttributeValue{M: ms.item}
 	return "{" + strings.Join(arrEle, ",") + "}"
 }
 
@@ -128,10 +691,100 @@ func newMapValueEncoder(t reflect.Type) valueEncoderFunc {
 	if t.Key().Kind() != reflect.String {
 		return valueUnsupportedTypeEncoder
 	}
+	if isSetMap(t) {
+		return setMapValueEncoder
+	}
 	enc := &mapValueEncoder{valueEncoder(t.Elem())}
 	return enc.encode
 }
 
+// reports whether the map type t is a set, holding no more than its keys
+// (as map[string]struct{} does)
+func isSetMap(t reflect.Type) bool {
+	et := t.Elem()
+	return et.Kind() == reflect.Struct && et.NumField() == 0
+}
+
+// A set map (map[string]struct{}) is stored as an SS of its keys, in
+// sorted order. As sets cannot be empty in dynamoDB an empty set map is
+// left out, like a nil one.
+func setMapValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
+	if v.Len() == 0 {
+		return "[]"
+	}
+	arrEle := make([]string, 0, v.Len())
+	for _, k := range v.MapKeys() {
+		arrEle = append(arrEle, k.String())
+	}
+	sort.Strings(arrEle)
+	arrPtr := make([]*string, len(arrEle))
+	for i := range arrEle {
+		arrPtr[i] = &arrEle[i]
+	}
+	if e != nil {
+		e.item[n] = &dynamodb.AttributeValue{SS: arrPtr}
+	}
+	return "[" + strings.Join(arrEle, ",") + "]"
+}
+
+// reports whether sf is a map[string]bool or a map of integers to bool
+// tagged `dynaGo:",set"`, which is stored as the set of its keys mapped
+// to true, an SS or an NS. The keys mapped to false are dropped, and the
+// map decodes with every key it has mapped to true. As with a set map an
+// empty set is left out.
+func isBoolSetMap(sf reflect.StructField) bool {
+	t := sf.Type
+	if t.Kind() != reflect.Map || t.Elem().Kind() != reflect.Bool {
+		return false
+	}
+	_, o := parseTag(sf.Tag.Get("dynaGo"))
+	if !o.Contains("set") {
+		return false
+	}
+	switch t.Key().Kind() {
+	case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	panic(&TagOptionError{sf.Name, "set"})
+}
+
+func boolSetMapValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
+	ks := make([]reflect.Value, 0, v.Len())
+	for _, k := range v.MapKeys() {
+		if v.MapIndex(k).Bool() {
+			ks = append(ks, k)
+		}
+	}
+	if len(ks) == 0 {
+		return "[]"
+	}
+	num := v.Type().Key().Kind() != reflect.String
+	sort.Slice(ks, func(a, b int) bool {
+		if num {
+			return ks[a].Int() < ks[b].Int()
+		}
+		return ks[a].String() < ks[b].String()
+	})
+	arrEle := make([]string, len(ks))
+	arrPtr := make([]*string, len(ks))
+	for i, k := range ks {
+		if num {
+			arrEle[i] = strconv.FormatInt(k.Int(), 10)
+		} else {
+			arrEle[i] = k.String()
+		}
+		arrPtr[i] = &arrEle[i]
+	}
+	if e != nil {
+		if num {
+			e.item[n] = &dynamodb.AttributeValue{NS: arrPtr}
+		} else {
+			e.item[n] = &dynamodb.AttributeValue{SS: arrPtr}
+		}
+	}
+	return "[" + strings.Join(arrEle, ",") + "]"
+}
+
 // the pointer will have a single sustained type no matter how
 // many times we use this encoder to encode it, so we cache the
 // valueEncoderFunc to avoid type lookup everytime we use it
@@ -0,0 +1,278 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// Encoder carries the configuration used to build dynamoDB inputs from
+// structs. Unset options fall back to the package defaults, so the
+// package level functions (Marshal, TableName, Update, ...) behave like
+// the methods of a new Encoder.
+type Encoder struct {
+	prefix *string
+	suffix *string
+	namer  func(string) string
+	cipher FieldCipher
+	retry  *RetryPolicy
+	ttl    time.Duration
+	// called after each item is marshaled, if set
+	encoded func(t reflect.Type, attrs int)
+}
+
+// the Encoder behind the package level functions
+var std = NewEncoder()
+
+func NewEncoder() *Encoder {
+	return &Encoder{}
+}
+
+// Clone snapshots the configuration of the package level functions (see
+// the package level Set functions) into a new Encoder and Decoder, which
+// library code or a server handling a request can configure further
+// without touching the package's. Later changes to the package's
+// configuration don't reach the clones either. Without a prefix of its
+// own the Encoder still names tables with DYNAGO_PREFIX.
+func Clone() (*Encoder, *Decoder) {
+	return std.Clone(), stdDecoder.Clone()
+}
+
+// Clone returns a copy of enc sharing no configuration with it.
+func (enc *Encoder) Clone() *Encoder {
+	c := *enc
+	if enc.prefix != nil {
+		p := *enc.prefix
+		c.prefix = &p
+	}
+	if enc.suffix != nil {
+		s := *enc.suffix
+		c.suffix = &s
+	}
+	if enc.retry != nil {
+		r := *enc.retry
+		c.retry = &r
+	}
+	return &c
+}
+
+// WithPrefix returns a copy of enc which names tables with prefix in
+// place of the DYNAGO_PREFIX environment variable, joined with the same
+// "_" separator. The default prefix is left untouched, so tools moving
+// data between tenant prefixes can hold one Encoder per prefix.
+func (enc *Encoder) WithPrefix(prefix string) *Encoder {
+	c := *enc
+	c.prefix = &prefix
+	return &c
+}
+
+// SetNameTransformer names the attributes of untagged fields with f,
+// called with the Go field name (e.g. to store CreatedAt as created_at),
+// those of keyless structs stored as maps within the item included.
+// Fields naming their attribute in the dynaGo tag bypass f. Decode with a
+// Decoder given the same transformer. A nil f restores the Go names.
+func (enc *Encoder) SetNameTransformer(f func(goFieldName string) string) {
+	enc.namer = f
+}
+
+// SetFieldCipher encrypts the fields with the option encrypt
+// (`dynaGo:",encrypt"`) with f, storing the cipher text as a B. f is
+// given the bytes of the attribute the field would otherwise be: the
+// string of an S or N, or a B. Only such scalar fields can be encrypted,
+// and not keys of the table. Marshaling an encrypt field without a
+// cipher fails with a FieldCipherError. The fields are left readable to
+// Decoders given the inverse of f with Decoder.SetFieldCipher.
+func (enc *Encoder) SetFieldCipher(f FieldCipher) {
+	enc.cipher = f
+}
+
+// SetRetryPolicy paces the retries of the runners with p in place of
+// DefaultRetryPolicy. The runners are package level functions, so it is
+// the policy of the package level Encoder (set by SetRetryPolicy) which
+// they follow.
+func (enc *Encoder) SetRetryPolicy(p RetryPolicy) {
+	enc.retry = &p
+}
+
+func (enc *Encoder) retryPolicy() RetryPolicy {
+	if enc.retry != nil {
+		return *enc.retry
+	}
+	return DefaultRetryPolicy()
+}
+
+// SetDefaultTTL has a TTL field (`dynaGo:",TTL"`, see isTTL) left zero
+// expire d after the item is marshaled, storing the epoch seconds of now
+// plus d. TTL fields which are set keep their value. A d of 0, the
+// default, leaves zero TTL fields out.
+func (enc *Encoder) SetDefaultTTL(d time.Duration) {
+	enc.ttl = d
+}
+
+// OnItemEncoded calls f after each item Marshal (and Put) encodes, with
+// the type of the item and the number of attributes written, for metrics
+// or debugging. A nil f, the default, stops the calls.
+func (enc *Encoder) OnItemEncoded(f func(t reflect.Type, attrs int)) {
+	enc.encoded = f
+}
+
+// TableName resolves the name of the table holding items of type t. An
+// anonymous struct type has no name to give its table, and panics with an
+// AnonymousTypeError (returned by the functions returning errors): write
+// such values to a table named explicitly with MarshalInto.
+func (enc *Encoder) TableName(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Name() == "" {
+		panic(&AnonymousTypeError{t})
+	}
+	return enc.tablePrefix() + t.Name() + enc.tableSuffix()
+}
+
+// TableNames resolves the names of the tables holding vs, values (or
+// pointers to values) of the types stored, in the order given. It panics
+// as TableName does.
+func (enc *Encoder) TableNames(vs ...interface{}) []string {
+	tns := make([]string, len(vs))
+	for n, v := range vs {
+		tns[n] = enc.TableName(reflect.TypeOf(v))
+	}
+	return tns
+}
+
+// SetTableSuffix replaces the "s" appended to type names to name their
+// tables, "" naming a table after its type alone.
+func (enc *Encoder) SetTableSuffix(suffix string) {
+	enc.suffix = &suffix
+}
+
+func (enc *Encoder) tableSuffix() string {
+	if enc.suffix != nil {
+		return *enc.suffix
+	}
+	return "s"
+}
+
+// TableName which returns a missing prefix instead of panicking
+func (enc *Encoder) tableName(t reflect.Type) (tn string, err error) {
+	defer recoverError(&err)
+	return enc.TableName(t), nil
+}
+
+func (enc *Encoder) tablePrefix() string {
+	if enc.prefix != nil {
+		return *enc.prefix + "_"
+	}
+	return tablePrefix()
+}
+
+// Marshal is the same as the package level Marshal, with the table
+// named by enc.
+func (enc *Encoder) Marshal(i interface{}) *dynamodb.PutItemInput {
+	e := &valueEncoderState{make(map[string]*dynamodb.AttributeValue), enc.namer}
+	encode(e, i, enc.namer)
+	encryptFields(e.item, reflect.Indirect(reflect.ValueOf(i)).Type(), enc.namer, enc.cipher)
+	fillTTL(e.item, reflect.Indirect(reflect.ValueOf(i)).Type(), enc.namer, enc.ttl)
+	if enc.encoded != nil {
+		enc.encoded(reflect.Indirect(reflect.ValueOf(i)).Type(), len(e.item))
+	}
+	tn := enc.TableName(reflect.TypeOf(i))
+	return &dynamodb.PutItemInput{Item: e.item, TableName: &tn}
+}
+
+// MarshalInto is the same as the package level MarshalInto, with
+// attributes named by enc.
+func (enc *Encoder) MarshalInto(tableName string, i interface{}) (pi *dynamodb.PutItemInput, err error) {
+	defer recoverError(&err)
+	e := &valueEncoderState{make(map[string]*dynamodb.AttributeValue), enc.namer}
+	encode(e, i, enc.namer)
+	encryptFields(e.item, reflect.Indirect(reflect.ValueOf(i)).Type(), enc.namer, enc.cipher)
+	fillTTL(e.item, reflect.Indirect(reflect.ValueOf(i)).Type(), enc.namer, enc.ttl)
+	if enc.encoded != nil {
+		enc.encoded(reflect.Indirect(reflect.ValueOf(i)).Type(), len(e.item))
+	}
+	return &dynamodb.PutItemInput{Item: e.item, TableName: &tableName}, nil
+}
+
+// MarshalFragment is the same as the package level MarshalFragment,
+// with attributes named by enc.
+func (enc *Encoder) MarshalFragment(i interface{}) (m map[string]*dynamodb.AttributeValue, err error) {
+	defer recoverError(&err)
+	e := &valueEncoderState{make(map[string]*dynamodb.AttributeValue), enc.namer}
+	encode(e, i, enc.namer)
+	encryptFields(e.item, reflect.Indirect(reflect.ValueOf(i)).Type(), enc.namer, enc.cipher)
+	return e.item, nil
+}
+
+// Marshal which returns encoding failures instead of panicking
+func (enc *Encoder) marshal(i interface{}) (pi *dynamodb.PutItemInput, err error) {
+	defer recoverError(&err)
+	return enc.Marshal(i), nil
+}
+
+// KeyMap is the same as the package level KeyMap, with attributes named
+// by enc.
+func (enc *Encoder) KeyMap(i interface{}) (map[string]*dynamodb.AttributeValue, error) {
+	return keyMap(i, enc.namer)
+}
+
+// Get begins a GetItemInput for the key of i, with the table named by enc.
+func (enc *Encoder) Get(i interface{}) *GetBuilder {
+	return &GetBuilder{enc: enc, i: i}
+}
+
+// Scan begins a ScanInput over the table holding v, named by enc.
+func (enc *Encoder) Scan(v interface{}) *ScanBuilder {
+	return &ScanBuilder{enc: enc, v: v}
+}
+
+// Update begins an UpdateItemInput for i, with the table named by enc.
+func (enc *Encoder) Update(i interface{}) *UpdateBuilder {
+	return &UpdateBuilder{enc: enc, i: i}
+}
+
+// Put begins a PutItemInput for i, with the table named by enc.
+func (enc *Encoder) Put(i interface{}) *PutBuilder {
+	return &PutBuilder{enc: enc, i: i}
+}
+
+// Delete begins a DeleteItemInput for i, with the table named by enc.
+func (enc *Encoder) Delete(i interface{}) *DeleteBuilder {
+	return &DeleteBuilder{enc: enc, i: i}
+}
+
+// Upsert is the same as the package level Upsert, with the item written
+// and its attributes named by enc.
+func (enc *Encoder) Upsert(svc dynamodbiface.DynamoDBAPI, i interface{}, updateFields ...string) (err error) {
+	defer recoverError(&err)
+	t := reflect.Indirect(reflect.ValueOf(i)).Type()
+	if t.Kind() != reflect.Struct {
+		return &OnlyStructsSupportedError{t.Kind()}
+	}
+	pk := t.Field(getPartitionKey(t)[0]).Name
+	pi, err := enc.Put(i).If(Filter(pk, "attribute_not_exists", nil)).Input()
+	if err != nil {
+		return err
+	}
+	_, err = svc.PutItem(pi)
+	if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != dynamodb.ErrCodeConditionalCheckFailedException {
+		return err
+	}
+	if len(updateFields) == 0 {
+		return nil
+	}
+	ui, err := enc.Update(i).FieldMask(updateFields...).Input()
+	if err != nil {
+		return err
+	}
+	_, err = svc.UpdateItem(ui)
+	return err
+}
@@ -0,0 +1,376 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncoderWithPrefix(t *testing.T) {
+	a := NewEncoder().WithPrefix("TENANT_A")
+	b := a.WithPrefix("TENANT_B")
+	if tn := *a.Marshal(usr0).TableName; tn != "TENANT_A_Usrs" {
+		t.Errorf("failed: table name %s", tn)
+	}
+	if tn := *b.Marshal(&usr0).TableName; tn != "TENANT_B_Usrs" {
+		t.Errorf("failed: table name %s", tn)
+	}
+	if tn := *Marshal(usr0).TableName; tn != tablePrefix()+"Usrs" {
+		t.Errorf("failed: default table name %s", tn)
+	}
+}
+
+type Reading struct {
+	DeviceId   string `dynaGo:"device,HASH"`
+	TakenAt    int64  `dynaGo:",RANGE"`
+	SensorName string
+	MaxValue   int
+	IsActive   bool
+}
+
+// CamelCase to snake_case, enough for the field names used here
+func snakeCase(s string) string {
+	b := make([]byte, 0, len(s)+4)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' {
+			if i > 0 {
+				b = append(b, '_')
+			}
+			c += 'a' - 'A'
+		}
+		b = append(b, c)
+	}
+	return string(b)
+}
+
+func TestEncoderNameTransformer(t *testing.T) {
+	enc := NewEncoder()
+	enc.SetNameTransformer(snakeCase)
+	r := Reading{DeviceId: "d1", TakenAt: 10, SensorName: "temp", MaxValue: 40, IsActive: true}
+	item := enc.Marshal(r).Item
+	for _, an := range []string{"device", "taken_at", "sensor_name", "max_value", "is_active"} {
+		if _, ok := item[an]; !ok {
+			t.Errorf("failed: no attribute %s in %v", an, item)
+		}
+	}
+	if len(item) != 5 {
+		t.Errorf("failed: expected 5 attributes, found %d", len(item))
+	}
+	key, err := enc.KeyMap(r)
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if _, ok := key["taken_at"]; !ok || len(key) != 2 {
+		t.Errorf("failed: key %v", key)
+	}
+	if _, ok := Marshal(r).Item["SensorName"]; !ok {
+		t.Error("failed: package Marshal picked up the transformer")
+	}
+}
+
+// every entry point taking a struct accepts it by value or by pointer
+func TestEntryPointsAcceptPointers(t *testing.T) {
+	o := Order{Customer: "c1", OrderId: "o1", CreatedAt: 10, Total: 5}
+	entry := map[string]func(i interface{}) (interface{}, error){
+		"Marshal":       func(i interface{}) (interface{}, error) { return std.marshal(i) },
+		"KeyMap":        func(i interface{}) (interface{}, error) { return KeyMap(i) },
+		"Get":           func(i interface{}) (interface{}, error) { return Get(i).Input() },
+		"Put":           func(i interface{}) (interface{}, error) { return Put(i).Input() },
+		"Update":        func(i interface{}) (interface{}, error) { return Update(i).Input() },
+		"Delete":        func(i interface{}) (interface{}, error) { return Delete(i).Input() },
+		"Query":         func(i interface{}) (interface{}, error) { return Query(i).Input() },
+		"ScanInput":     func(i interface{}) (interface{}, error) { return ScanInput(i) },
+		"ItemSize":      func(i interface{}) (interface{}, error) { return ItemSize(i) },
+		"Validate":      func(i interface{}) (interface{}, error) { return nil, Validate(i) },
+		"CreateTable":   func(i interface{}) (interface{}, error) { return BuildCreateTableInput(i, 1, 1) },
+		"WriteRequests": func(i interface{}) (interface{}, error) { return WriteRequests(i) },
+	}
+	for name, f := range entry {
+		byValue, err := f(o)
+		if err != nil {
+			t.Errorf("failed: %s by value: %s", name, err)
+			continue
+		}
+		byPtr, err := f(&o)
+		if err != nil {
+			t.Errorf("failed: %s by pointer: %s", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(byValue, byPtr) {
+			t.Errorf("failed: %s differs by pointer: %v, %v", name, byValue, byPtr)
+		}
+	}
+	if _, err := Get((*Order)(nil)).Input(); err == nil {
+		t.Error("failed: expected error for a nil pointer")
+	}
+	if err := Validate(new(int)); err == nil {
+		t.Error("failed: expected error for a pointer to int")
+	}
+}
+
+func TestEncoderTableSuffix(t *testing.T) {
+	enc := NewEncoder().WithPrefix("APP")
+	enc.SetTableSuffix("")
+	if tn := *enc.Marshal(usr0).TableName; tn != "APP_Usr" {
+		t.Errorf("failed: table name %s", tn)
+	}
+	enc.SetTableSuffix("_table")
+	if tn := enc.TableName(reflect.TypeOf(&usr0)); tn != "APP_Usr_table" {
+		t.Errorf("failed: table name %s", tn)
+	}
+
+	SetTableSuffix("")
+	defer SetTableSuffix("s")
+	if tn := *Marshal(usr0).TableName; tn != tablePrefix()+"Usr" {
+		t.Errorf("failed: default table name %s", tn)
+	}
+}
+
+func TestTableNames(t *testing.T) {
+	enc := NewEncoder().WithPrefix("APP")
+	enc.SetTableSuffix("_table")
+	got := enc.TableNames(usr0, &Order{}, Message{}, Account{})
+	want := []string{"APP_Usr_table", "APP_Order_table", "APP_Message_table", "APP_Account_table"}
+	if !equalStrings(got, want) {
+		t.Errorf("failed: table names %v, want %v", got, want)
+	}
+	got = TableNames(usr0, Playlist{})
+	want = []string{tablePrefix() + "Usrs", tablePrefix() + "Playlists"}
+	if !equalStrings(got, want) {
+		t.Errorf("failed: default table names %v, want %v", got, want)
+	}
+	if len(TableNames()) != 0 {
+		t.Error("failed: expected no table names")
+	}
+}
+
+func TestPrefixNotSet(t *testing.T) {
+	tablePrefix()
+	defer func(set bool) { prefixSet = set }(prefixSet)
+	prefixSet = false
+
+	if _, err := Put(usr0).Input(); err == nil {
+		t.Error("failed: Put expected PrefixNotSetError")
+	} else if _, ok := err.(*PrefixNotSetError); !ok {
+		t.Errorf("failed: Put returned %v", err)
+	}
+	if _, err := BuildCreateTableInput(usr0, 1, 1); err == nil {
+		t.Error("failed: BuildCreateTableInput expected PrefixNotSetError")
+	}
+	if _, err := Get(usr0).Input(); err == nil {
+		t.Error("failed: Get expected PrefixNotSetError")
+	}
+	// a prefix given to the Encoder needs no environment
+	if _, err := NewEncoder().WithPrefix("APP").Put(usr0).Input(); err != nil {
+		t.Errorf("failed: %s", err)
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("failed: Marshal didn't panic")
+		}
+	}()
+	Marshal(usr0)
+}
+
+func TestMarshalInto(t *testing.T) {
+	live, err := MarshalInto("Messages", msg)
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	audit, err := MarshalInto("MessagesAudit", &msg)
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if *live.TableName != "Messages" || *audit.TableName != "MessagesAudit" {
+		t.Errorf("failed: table names %s %s", *live.TableName, *audit.TableName)
+	}
+	if !reflect.DeepEqual(live.Item, audit.Item) || !reflect.DeepEqual(live.Item, Marshal(msg).Item) {
+		t.Errorf("failed: items differ %v %v", live.Item, audit.Item)
+	}
+	if _, err := MarshalInto("Numbers", 5); err == nil {
+		t.Error("failed: expected error for a non-struct")
+	}
+}
+
+func TestFieldCipher(t *testing.T) {
+	type Patient struct {
+		Id    string `dynaGo:",HASH"`
+		Name  string `dynaGo:",encrypt"`
+		Age   int    `dynaGo:",encrypt"`
+		Notes []byte `dynaGo:",encrypt"`
+		Ward  string
+	}
+	xor := func(in []byte) ([]byte, error) {
+		out := make([]byte, len(in))
+		for n, b := range in {
+			out[n] = b ^ 0x5a
+		}
+		return out, nil
+	}
+	enc := NewEncoder()
+	enc.SetFieldCipher(xor)
+	p := Patient{Id: "p1", Name: "Ada", Age: 36, Notes: []byte{1, 2}, Ward: "B"}
+	item := enc.Marshal(p).Item
+	if item["Name"].B == nil || string(item["Name"].B) == "Ada" || item["Age"].B == nil || item["Notes"].B == nil {
+		t.Errorf("failed: encrypted attributes %v", item)
+	}
+	if *item["Id"].S != "p1" || *item["Ward"].S != "B" {
+		t.Errorf("failed: plain attributes %v", item)
+	}
+
+	dec := NewDecoder()
+	dec.SetFieldCipher(xor)
+	var got Patient
+	if err := dec.Unmarshal(item, &got); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if !reflect.DeepEqual(got, p) {
+		t.Errorf("failed: round trip %+v", got)
+	}
+
+	var fce *FieldCipherError
+	if err := Unmarshal(item, &got); !errors.As(err, &fce) {
+		t.Errorf("failed: expected FieldCipherError without a cipher, got %v", err)
+	}
+	if _, err := Put(p).Input(); !errors.As(err, &fce) {
+		t.Errorf("failed: expected FieldCipherError without a cipher, got %v", err)
+	}
+	type Secret struct {
+		Id string `dynaGo:",HASH,encrypt"`
+	}
+	var toe *TagOptionError
+	if _, err := enc.MarshalInto("Secrets", Secret{"s"}); !errors.As(err, &toe) {
+		t.Errorf("failed: expected TagOptionError for encrypted key, got %v", err)
+	}
+}
+
+func TestAnonymousTypeTableName(t *testing.T) {
+	v := struct {
+		Id string `dynaGo:",HASH"`
+	}{"a1"}
+	var ate *AnonymousTypeError
+	if _, err := Put(v).Input(); !errors.As(err, &ate) {
+		t.Errorf("failed: expected AnonymousTypeError, got %v", err)
+	}
+	pi, err := MarshalInto("Adhoc", v)
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if *pi.TableName != "Adhoc" || *pi.Item["Id"].S != "a1" {
+		t.Errorf("failed: put %v", pi)
+	}
+}
+
+func TestMarshalFragment(t *testing.T) {
+	type Address struct {
+		Street string
+		Number int
+		Note   string `dynaGo:"note"`
+	}
+	m, err := MarshalFragment(&Address{Street: "Main", Number: 4})
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if len(m) != 2 || *m["Street"].S != "Main" || *m["Number"].N != "4" {
+		t.Errorf("failed: fragment %v", m)
+	}
+	if _, err := MarshalFragment(4); err == nil {
+		t.Error("failed: expected error for a non-struct fragment")
+	}
+}
+
+func TestDefaultTTL(t *testing.T) {
+	type Token struct {
+		Id        string    `dynaGo:",HASH"`
+		ExpiresAt time.Time `dynaGo:",TTL"`
+	}
+	type Lease struct {
+		Id     string `dynaGo:",HASH"`
+		Expiry int64  `dynaGo:"ttl,TTL"`
+	}
+	enc := NewEncoder()
+	if _, ok := enc.Marshal(Token{Id: "t0"}).Item["ExpiresAt"]; ok {
+		t.Error("failed: zero TTL written without a default")
+	}
+	enc.SetDefaultTTL(30 * 24 * time.Hour)
+	before := time.Now().Add(30 * 24 * time.Hour).Unix()
+	item := enc.Marshal(Token{Id: "t1"}).Item
+	secs, err := strconv.ParseInt(*item["ExpiresAt"].N, 10, 64)
+	if err != nil || secs < before || secs > before+60 {
+		t.Errorf("failed: zero TTL filled with %v", item["ExpiresAt"])
+	}
+	if item := enc.Marshal(&Lease{Id: "l1"}).Item; item["ttl"] == nil || item["ttl"].N == nil {
+		t.Errorf("failed: zero int TTL filled with %v", item["ttl"])
+	}
+
+	set := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	item = enc.Marshal(Token{Id: "t2", ExpiresAt: set}).Item
+	if *item["ExpiresAt"].N != strconv.FormatInt(set.Unix(), 10) {
+		t.Errorf("failed: set TTL stored as %v", item["ExpiresAt"])
+	}
+	var tok Token
+	if err := Unmarshal(item, &tok); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if !tok.ExpiresAt.Equal(set) {
+		t.Errorf("failed: TTL decoded as %s", tok.ExpiresAt)
+	}
+	if item := enc.Marshal(Lease{Id: "l2", Expiry: 1900000000}).Item; *item["ttl"].N != "1900000000" {
+		t.Errorf("failed: set int TTL stored as %v", item["ttl"])
+	}
+
+	type Twice struct {
+		Id string    `dynaGo:",HASH"`
+		A  time.Time `dynaGo:",TTL"`
+		B  int64     `dynaGo:",TTL"`
+	}
+	if err := Validate(Twice{}); err == nil {
+		t.Error("failed: expected TagOptionError for two TTL fields")
+	}
+}
+
+func TestClone(t *testing.T) {
+	enc, dec := Clone()
+	enc.SetTableSuffix("_v2")
+	enc.SetNameTransformer(strings.ToLower)
+	enc.SetRetryPolicy(RetryPolicy{MaxAttempts: 1})
+	if tn := TableName(reflect.TypeOf(usr0)); tn != tablePrefix()+"Usrs" {
+		t.Errorf("failed: default table name %s", tn)
+	}
+	if tn := enc.TableName(reflect.TypeOf(usr0)); tn != tablePrefix()+"Usr_v2" {
+		t.Errorf("failed: clone table name %s", tn)
+	}
+	if _, ok := Marshal(usr0).Item["Email"]; !ok {
+		t.Error("failed: the clone's namer reached the default")
+	}
+	if _, ok := enc.Marshal(usr0).Item["email"]; !ok {
+		t.Error("failed: the clone's namer wasn't applied")
+	}
+	if std.retryPolicy() != DefaultRetryPolicy() {
+		t.Errorf("failed: default retry policy %+v", std.retryPolicy())
+	}
+
+	SetTableSuffix("_v3")
+	if tn := enc.TableName(reflect.TypeOf(usr0)); tn != tablePrefix()+"Usr_v2" {
+		t.Errorf("failed: the default's suffix reached the clone, %s", tn)
+	}
+	SetTableSuffix("s")
+
+	dec.SetStrict(true)
+	item := Marshal(usr0).Item
+	item["Unknown"] = item["Email"]
+	if err := Unmarshal(item, &Usr{}); err != nil {
+		t.Errorf("failed: the clone's strictness reached the default, %s", err)
+	}
+	if err := dec.Unmarshal(item, &Usr{}); err == nil {
+		t.Error("failed: expected the strict clone to reject the attribute")
+	}
+}
@@ -1,7 +1,23 @@
 package dynaGo
 
+// The errors of dynaGo are returned as pointers to the types declared
+// here, match them with errors.As into a pointer:
+//
+//	var mk *MissingKeyError
+//	if errors.As(err, &mk) { ... mk.KeyType ... }
+//
+// The exceptions, returned as values, are TableExistsError (as CreateTable
+// always has), ScanSegmentsError, and the errors of decoding declared in
+// decode.go (InvalidDecodeError aside). MissingKeyError and
+// TableExistsError also match errors.Is against a zero value of their
+// own, errors.Is(err, &MissingKeyError{}) reporting any missing key.
+
 import (
 	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 type TableExistsError struct {
@@ -12,23 +28,55 @@ func (e TableExistsError) Error() string {
 	return "dynaGo: Table named " + e.TableName + " already exists."
 }
 
+// Is matches any TableExistsError to TableExistsError{}, and otherwise
+// one of the same table.
+func (e TableExistsError) Is(target error) bool {
+	t, ok := target.(TableExistsError)
+	return ok && (t.TableName == "" || t.TableName == e.TableName)
+}
+
 type UnsupportedKindError struct {
 	Kind reflect.Kind
 }
 
-func (e UnsupportedKindError) Error() string {
+func (e *UnsupportedKindError) Error() string {
 	return "dynaGo: unsuppoted kind: " + e.Kind.String()
 }
 
+// MissingKeyError reports a type without the key of KeyType it needs.
+// RangeField names the type's RANGE key field when it is the HASH key
+// which is missing, as a RANGE is no key at all without one.
 type MissingKeyError struct {
-	Type    reflect.Type
-	KeyType string
+	Type       reflect.Type
+	KeyType    string
+	RangeField string
 }
 
-func (e MissingKeyError) Error() string {
+func (e *MissingKeyError) Error() string {
+	if e.RangeField != "" {
+		return "dynaGo: found RANGE key " + e.RangeField + " but no HASH key: " + e.Type.String()
+	}
 	return "dynaGo: Type missing " + e.KeyType + " key: " + e.Type.String()
 }
 
+// Is matches any MissingKeyError to &MissingKeyError{}, and otherwise one
+// with the same fields set.
+func (e *MissingKeyError) Is(target error) bool {
+	t, ok := target.(*MissingKeyError)
+	return ok && (t.Type == nil || t.Type == e.Type) && (t.KeyType == "" || t.KeyType == e.KeyType)
+}
+
+// MissingKeyAttributeError reports a key attribute of Type absent from
+// the key map decoded.
+type MissingKeyAttributeError struct {
+	Type      reflect.Type
+	Attribute string
+}
+
+func (e *MissingKeyAttributeError) Error() string {
+	return "dynaGo: key of " + e.Type.String() + " missing attribute " + e.Attribute
+}
+
 type KeyTypeNotFoundError struct {
 	Type reflect.Type
 }
@@ -85,3 +133,412 @@ type UnsupportedKeyKindError struct {
 func (e *UnsupportedKeyKindError) Error() string {
 	return "dynaGo: partitionkey has unsupported kind - " + e.Kind.String()
 }
+
+type UnknownFieldError struct {
+	Type      reflect.Type
+	FieldName string
+}
+
+func (e *UnknownFieldError) Error() string {
+	return "dynaGo: " + e.Type.String() + " has no field " + e.FieldName
+}
+
+type KeyFieldUpdateError struct {
+	FieldName string
+}
+
+func (e *KeyFieldUpdateError) Error() string {
+	return "dynaGo: key field " + e.FieldName + " cannot be updated"
+}
+
+type ReadOnlyFieldError struct {
+	FieldName string
+}
+
+func (e *ReadOnlyFieldError) Error() string {
+	return "dynaGo: readonly field " + e.FieldName + " cannot be written"
+}
+
+type NilKeyValueError struct {
+	Type reflect.Type
+}
+
+func (e *NilKeyValueError) Error() string {
+	return "dynaGo: key of " + e.Type.String() + " passes through a nil pointer"
+}
+
+type NumberPrecisionError struct {
+	Value string
+}
+
+func (e *NumberPrecisionError) Error() string {
+	return "dynaGo: number exceeds dynamoDB precision or range: " + e.Value
+}
+
+// NonFiniteNumberError reports a NaN or infinite number, which dynamoDB
+// can't store.
+type NonFiniteNumberError struct {
+	Value string
+}
+
+func (e *NonFiniteNumberError) Error() string {
+	return "dynaGo: number is not finite: " + e.Value
+}
+
+type IndexKeyError struct {
+	IndexName string
+}
+
+func (e *IndexKeyError) Error() string {
+	return "dynaGo: local index " + e.IndexName + " needs a RANGE key and a table HASH key"
+}
+
+// UnknownIndexError reports an index which no field of Type tags.
+type UnknownIndexError struct {
+	Type      reflect.Type
+	IndexName string
+}
+
+func (e *UnknownIndexError) Error() string {
+	return "dynaGo: no index " + e.IndexName + " tagged on " + e.Type.String()
+}
+
+// IndexExistsError reports an index the table already has.
+type IndexExistsError struct {
+	TableName string
+	IndexName string
+}
+
+func (e *IndexExistsError) Error() string {
+	return "dynaGo: table " + e.TableName + " already has index " + e.IndexName
+}
+
+// IndexThroughputError reports an rcu or wcu option which isn't an index
+// name and a positive number of units, as in rcu=ByOrigin:10.
+type IndexThroughputError struct {
+	Option string
+}
+
+func (e *IndexThroughputError) Error() string {
+	return "dynaGo: invalid index capacity " + e.Option + ", want IndexName:units"
+}
+
+// OmitEmptyKeyError reports a key of the table tagged omitempty, which
+// every item must carry.
+type OmitEmptyKeyError struct {
+	Type      reflect.Type
+	FieldName string
+}
+
+func (e *OmitEmptyKeyError) Error() string {
+	return "dynaGo: key field " + e.FieldName + " of " + e.Type.String() + " cannot be omitempty"
+}
+
+// AttributeNameError reports the attribute name of a field which DynamoDB
+// would reject, or which can't be written into an expression unaliased.
+type AttributeNameError struct {
+	Type          reflect.Type
+	FieldName     string
+	AttributeName string
+	Reason        string
+}
+
+func (e *AttributeNameError) Error() string {
+	return "dynaGo: attribute name " + strconv.Quote(e.AttributeName) + " of field " +
+		e.FieldName + " of " + e.Type.String() + " " + e.Reason
+}
+
+// TagOptionError reports an option of the dynaGo tag of a field which
+// can't be used as given.
+type TagOptionError struct {
+	FieldName string
+	Option    string
+}
+
+func (e *TagOptionError) Error() string {
+	return "dynaGo: invalid tag option " + e.Option + " on field " + e.FieldName
+}
+
+// PaddedIntError reports an integer which can't be zero padded to Width
+// digits, being negative or too wide.
+type PaddedIntError struct {
+	Value int64
+	Width int
+}
+
+func (e *PaddedIntError) Error() string {
+	return "dynaGo: " + strconv.FormatInt(e.Value, 10) + " cannot be padded to " + strconv.Itoa(e.Width) + " digits"
+}
+
+// FieldCipherError reports the failure of the FieldCipher (or the lack
+// of one) for the encrypt field stored as Attribute.
+type FieldCipherError struct {
+	Attribute string
+	Err       error
+}
+
+func (e *FieldCipherError) Error() string {
+	return "dynaGo: cipher of attribute " + e.Attribute + ": " + e.Err.Error()
+}
+
+func (e *FieldCipherError) Unwrap() error {
+	return e.Err
+}
+
+// ScalingRangeError reports auto scaling bounds of read or write
+// capacity which aren't at least 1, or whose Min exceeds their Max.
+type ScalingRangeError struct {
+	Capacity string
+	Min, Max int64
+}
+
+func (e *ScalingRangeError) Error() string {
+	return "dynaGo: invalid " + e.Capacity + " capacity scaling range " +
+		strconv.FormatInt(e.Min, 10) + " to " + strconv.FormatInt(e.Max, 10)
+}
+
+// AnonymousTypeError reports an unnamed type, which names no table.
+type AnonymousTypeError struct {
+	Type reflect.Type
+}
+
+func (e *AnonymousTypeError) Error() string {
+	return "dynaGo: anonymous type " + e.Type.String() + " names no table, use MarshalInto"
+}
+
+// DocumentPathError reports a document path which doesn't parse.
+type DocumentPathError struct {
+	Path string
+}
+
+func (e *DocumentPathError) Error() string {
+	return "dynaGo: invalid document path " + strconv.Quote(e.Path)
+}
+
+type ReturnValuesError struct {
+	Operation    string
+	ReturnValues string
+}
+
+func (e *ReturnValuesError) Error() string {
+	return "dynaGo: " + e.Operation + " does not support ReturnValues " + e.ReturnValues
+}
+
+type ReturnConsumedCapacityError struct {
+	ReturnConsumedCapacity string
+}
+
+func (e *ReturnConsumedCapacityError) Error() string {
+	return "dynaGo: unknown ReturnConsumedCapacity " + e.ReturnConsumedCapacity
+}
+
+type UnsupportedOutputError struct {
+	Type reflect.Type
+}
+
+func (e *UnsupportedOutputError) Error() string {
+	if e.Type == nil {
+		return "dynaGo: no attributes to decode from nil output"
+	}
+	return "dynaGo: no attributes to decode from " + e.Type.String()
+}
+
+type DuplicateAttributeError struct {
+	Type          reflect.Type
+	AttributeName string
+	FieldName     string
+	OtherField    string
+}
+
+func (e *DuplicateAttributeError) Error() string {
+	return "dynaGo: fields " + e.FieldName + " and " + e.OtherField + " of " +
+		e.Type.String() + " both map to attribute " + e.AttributeName
+}
+
+type SchemaMismatchError struct {
+	TableName string
+	Want      string
+	Found     string
+}
+
+func (e *SchemaMismatchError) Error() string {
+	return "dynaGo: Table " + e.TableName + " has key schema " + e.Found + ", expected " + e.Want
+}
+
+type UnsupportedOperatorError struct {
+	Operator string
+}
+
+func (e *UnsupportedOperatorError) Error() string {
+	return "dynaGo: unsupported condition operator " + e.Operator
+}
+
+// EmptyConditionError reports an And or Or of no conditions.
+type EmptyConditionError struct {
+	Operator string
+}
+
+func (e *EmptyConditionError) Error() string {
+	return "dynaGo: " + e.Operator + " of no conditions"
+}
+
+type UnsupportedInputError struct {
+	Type reflect.Type
+}
+
+func (e *UnsupportedInputError) Error() string {
+	if e.Type == nil {
+		return "dynaGo: cannot apply expression to nil input"
+	}
+	return "dynaGo: cannot apply expression to " + e.Type.String()
+}
+
+type EmptyValueError struct {
+	Type reflect.Type
+}
+
+func (e *EmptyValueError) Error() string {
+	return "dynaGo: empty " + e.Type.String() + " has no attribute value"
+}
+
+type ItemTooLargeError struct {
+	Type reflect.Type
+	Size int
+}
+
+func (e *ItemTooLargeError) Error() string {
+	return "dynaGo: " + e.Type.String() + " item of " + strconv.Itoa(e.Size) +
+		" bytes exceeds the " + strconv.Itoa(MaxItemSize) + " byte limit"
+}
+
+type NotRangeKeyError struct {
+	Type      reflect.Type
+	FieldName string
+}
+
+func (e *NotRangeKeyError) Error() string {
+	return "dynaGo: field " + e.FieldName + " of " + e.Type.String() + " is not a RANGE key"
+}
+
+type TransactionSizeError struct {
+	Count int
+}
+
+func (e *TransactionSizeError) Error() string {
+	return "dynaGo: transaction of " + strconv.Itoa(e.Count) + " items exceeds the " +
+		strconv.Itoa(MaxTransactItems) + " item limit"
+}
+
+// ClientRequestTokenError reports a transaction token longer than
+// MaxClientRequestTokenLen.
+type ClientRequestTokenError struct {
+	Token string
+}
+
+func (e *ClientRequestTokenError) Error() string {
+	return "dynaGo: client request token of " + strconv.Itoa(len(e.Token)) + " characters exceeds the " +
+		strconv.Itoa(MaxClientRequestTokenLen) + " character limit"
+}
+
+// UnsupportedWriteError reports a value given to TransactWrite which isn't
+// a Put, Update or Delete builder.
+type UnsupportedWriteError struct {
+	Type reflect.Type
+}
+
+func (e *UnsupportedWriteError) Error() string {
+	if e.Type == nil {
+		return "dynaGo: cannot write nil in a transaction"
+	}
+	return "dynaGo: cannot write " + e.Type.String() + " in a transaction"
+}
+
+type ResponseCountError struct {
+	Responses int
+	Dests     int
+}
+
+func (e *ResponseCountError) Error() string {
+	return "dynaGo: " + strconv.Itoa(e.Responses) + " responses cannot be decoded into " +
+		strconv.Itoa(e.Dests) + " destinations"
+}
+
+type PrefixNotSetError struct {
+	Variable string
+}
+
+func (e *PrefixNotSetError) Error() string {
+	return "dynaGo: env " + e.Variable + " not set - no valid table prefix provided in environment"
+}
+
+type UnregisteredTypeError struct {
+	Name string
+}
+
+func (e *UnregisteredTypeError) Error() string {
+	return "dynaGo: no type registered as " + e.Name
+}
+
+type DiscriminatorError struct {
+	AttributeName string
+}
+
+func (e *DiscriminatorError) Error() string {
+	return "dynaGo: item has no string discriminator attribute " + e.AttributeName
+}
+
+// UnprocessedItemsError counts the requests (writes or keys) of a batch
+// dynamoDB still left unprocessed after the last of Attempts to submit
+// them.
+type UnprocessedItemsError struct {
+	Count    int
+	Attempts int
+}
+
+func (e *UnprocessedItemsError) Error() string {
+	return "dynaGo: " + strconv.Itoa(e.Count) + " batch requests unprocessed after " +
+		strconv.Itoa(e.Attempts) + " attempts"
+}
+
+// SegmentCountError reports a parallel scan asked for fewer than one
+// segment.
+type SegmentCountError struct {
+	Segments int
+}
+
+func (e *SegmentCountError) Error() string {
+	return "dynaGo: parallel scan of " + strconv.Itoa(e.Segments) + " segments, at least 1 is required"
+}
+
+// ScanSegmentsError holds the failures of the segments of a parallel
+// scan, by segment.
+type ScanSegmentsError map[int64]error
+
+func (e ScanSegmentsError) Error() string {
+	segments := make([]int, 0, len(e))
+	for s := range e {
+		segments = append(segments, int(s))
+	}
+	sort.Ints(segments)
+	msgs := make([]string, len(segments))
+	for n, s := range segments {
+		msgs[n] = "segment " + strconv.Itoa(s) + ": " + e[int64(s)].Error()
+	}
+	return "dynaGo: parallel scan failed: " + strings.Join(msgs, "; ")
+}
+
+// The encoders report failures by panicking with an error, this converts
+// such a panic back into a returned error for the helpers which return
+// one. Runtime errors, and panics that aren't errors, are not recovered.
+func recoverError(err *error) {
+	if r := recover(); r != nil {
+		if _, ok := r.(runtime.Error); ok {
+			panic(r)
+		}
+		e, ok := r.(error)
+		if !ok {
+			panic(r)
+		}
+		*err = e
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.13
+// +build go1.13
+
+package dynaGo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+type Keyless struct {
+	Name string
+}
+
+func TestErrorsAs(t *testing.T) {
+	_, err := BuildCreateTableInput(Keyless{}, 1, 1)
+	wrapped := fmt.Errorf("creating tables: %w", err)
+
+	var mk *MissingKeyError
+	if !errors.As(wrapped, &mk) {
+		t.Fatalf("failed: errors.As found no MissingKeyError in %v", wrapped)
+	}
+	if mk.Type != reflect.TypeOf(Keyless{}) || mk.KeyType != dynamodb.KeyTypeHash {
+		t.Errorf("failed: extracted %+v", mk)
+	}
+	if !errors.Is(wrapped, &MissingKeyError{}) {
+		t.Error("failed: errors.Is didn't match the zero MissingKeyError")
+	}
+	if !errors.Is(wrapped, &MissingKeyError{KeyType: dynamodb.KeyTypeHash}) {
+		t.Error("failed: errors.Is didn't match the HASH MissingKeyError")
+	}
+	if errors.Is(wrapped, &MissingKeyError{KeyType: dynamodb.KeyTypeRange}) {
+		t.Error("failed: errors.Is matched a RANGE MissingKeyError")
+	}
+
+	var te TableExistsError
+	exists := fmt.Errorf("ensure: %w", TableExistsError{"Usrs"})
+	if !errors.As(exists, &te) || te.TableName != "Usrs" {
+		t.Errorf("failed: errors.As extracted %+v", te)
+	}
+	if !errors.Is(exists, TableExistsError{}) || errors.Is(exists, TableExistsError{"Docs"}) {
+		t.Error("failed: errors.Is on TableExistsError")
+	}
+}
@@ -0,0 +1,359 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Condition is a comparison between a struct field and a value, or the
+// AND or OR of other Conditions, from which filter and condition
+// expressions are built.
+type Condition struct {
+	field string
+	op    string
+	value interface{}
+	// the operator joining conds, for an And or Or
+	join  string
+	conds []Condition
+}
+
+// Filter describes the condition "field op value". field is the name of
+// a Go field, resolved to its attribute name through its tag, or a
+// document path into one (Address.Zip, Items[0].Name, see pathSegment),
+// and op is one of =, <>, <, <=, > or >=. Conditions are combined with
+// AND, unless joined by Or.
+//
+// op may also be one of the functions
+//
+//	contains              the string attribute holds value as a substring,
+//	                      or the set attribute holds value as an element
+//	attribute_exists      the item has the attribute, value is ignored
+//	attribute_not_exists  the item lacks the attribute, value is ignored
+//
+// value is encoded as a field of its type would be: a bool compares with
+// a BOOL attribute, a []byte with a B, and a []string or slice of numbers
+// with an SS or NS set.
+func Filter(field, op string, value interface{}) Condition {
+	return Condition{field: field, op: op, value: value}
+}
+
+// And is the condition that all of conds hold. Conditions joining others
+// are parenthesized within the expression, so And(a, Or(b, c)) is
+// "a AND (b OR c)" whatever the precedence of AND and OR.
+func And(conds ...Condition) Condition {
+	return Condition{join: "AND", conds: conds}
+}
+
+// Or is the condition that any of conds holds, see And.
+func Or(conds ...Condition) Condition {
+	return Condition{join: "OR", conds: conds}
+}
+
+// ApplyFilter adds the AND of conds to the FilterExpression of in, a
+// *dynamodb.ScanInput or *dynamodb.QueryInput. v is the struct (or
+// pointer to struct) stored in the table, used to resolve field names.
+// Every attribute name and value is aliased in the expression, so names
+// which are dynamoDB reserved words need no special care, and aliases
+// already in the input are preserved. An existing FilterExpression is
+// kept, ANDed with the new conditions.
+func ApplyFilter(in interface{}, v interface{}, conds ...Condition) error {
+	return applyFilter(in, v, nil, conds)
+}
+
+// ApplyFilter with the attributes of untagged fields named by namer
+func applyFilter(in interface{}, v interface{}, namer func(string) string, conds []Condition) error {
+	var fe **string
+	var names *map[string]*string
+	var values *map[string]*dynamodb.AttributeValue
+	switch i := in.(type) {
+	case *dynamodb.ScanInput:
+		fe, names, values = &i.FilterExpression, &i.ExpressionAttributeNames, &i.ExpressionAttributeValues
+	case *dynamodb.QueryInput:
+		fe, names, values = &i.FilterExpression, &i.ExpressionAttributeNames, &i.ExpressionAttributeValues
+	default:
+		return &UnsupportedInputError{reflect.TypeOf(in)}
+	}
+	if len(conds) == 0 {
+		return nil
+	}
+	x := newExpression(reflect.TypeOf(v), namer, *names, *values)
+	expr, err := x.and(conds)
+	if err != nil {
+		return err
+	}
+	if *fe != nil {
+		expr = "(" + **fe + ") AND (" + expr + ")"
+	}
+	*fe, *names = &expr, x.names
+	if len(x.values) > 0 {
+		*values = x.values
+	}
+	return nil
+}
+
+// ApplyCondition adds the AND of conds to the ConditionExpression of in,
+// a *dynamodb.PutItemInput, *dynamodb.UpdateItemInput or
+// *dynamodb.DeleteItemInput, so the write only succeeds while the stored
+// item matches them. v, names and values are treated as by ApplyFilter,
+// and an existing ConditionExpression is likewise kept, ANDed with the
+// new conditions. The If methods of the Put, Update and Delete builders
+// apply their conditions this way, naming the attributes of untagged
+// fields as the Encoder they were begun from does.
+func ApplyCondition(in interface{}, v interface{}, conds ...Condition) error {
+	return applyCondition(in, v, nil, conds)
+}
+
+// ApplyCondition with the attributes of untagged fields named by namer
+func applyCondition(in interface{}, v interface{}, namer func(string) string, conds []Condition) error {
+	var ce **string
+	var names *map[string]*string
+	var values *map[string]*dynamodb.AttributeValue
+	switch i := in.(type) {
+	case *dynamodb.PutItemInput:
+		ce, names, values = &i.ConditionExpression, &i.ExpressionAttributeNames, &i.ExpressionAttributeValues
+	case *dynamodb.UpdateItemInput:
+		ce, names, values = &i.ConditionExpression, &i.ExpressionAttributeNames, &i.ExpressionAttributeValues
+	case *dynamodb.DeleteItemInput:
+		ce, names, values = &i.ConditionExpression, &i.ExpressionAttributeNames, &i.ExpressionAttributeValues
+	default:
+		return &UnsupportedInputError{reflect.TypeOf(in)}
+	}
+	if len(conds) == 0 {
+		return nil
+	}
+	x := newExpression(reflect.TypeOf(v), namer, *names, *values)
+	expr, err := x.and(conds)
+	if err != nil {
+		return err
+	}
+	if *ce != nil {
+		expr = "(" + **ce + ") AND (" + expr + ")"
+	}
+	*ce, *names = &expr, x.names
+	if len(x.values) > 0 {
+		*values = x.values
+	}
+	return nil
+}
+
+// ScanInput returns a ScanInput over the table holding v, filtered by
+// the AND of conds.
+func ScanInput(v interface{}, conds ...Condition) (*dynamodb.ScanInput, error) {
+	tn, err := std.tableName(reflect.TypeOf(v))
+	if err != nil {
+		return nil, err
+	}
+	si := &dynamodb.ScanInput{TableName: &tn}
+	if err := ApplyFilter(si, v, conds...); err != nil {
+		return nil, err
+	}
+	return si, nil
+}
+
+// expression accumulates the aliased attribute names and values used by
+// an expression over the struct type t.
+type expression struct {
+	t      reflect.Type
+	names  map[string]*string
+	values map[string]*dynamodb.AttributeValue
+	// names the attributes of the untagged fields of t and of the structs
+	// within it, as the Encoder writing the item does
+	namer func(string) string
+}
+
+func newExpression(t reflect.Type, namer func(string) string, names map[string]*string, values map[string]*dynamodb.AttributeValue) *expression {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	x := &expression{t, make(map[string]*string), make(map[string]*dynamodb.AttributeValue), namer}
+	for k, n := range names {
+		x.names[k] = n
+	}
+	for k, v := range values {
+		x.values[k] = v
+	}
+	return x
+}
+
+// aliases the attribute of the Go field named field
+func (x *expression) name(field string) (string, error) {
+	if x.t.Kind() != reflect.Struct {
+		return "", &OnlyStructsSupportedError{x.t.Kind()}
+	}
+	sf, ok := x.t.FieldByName(field)
+	if !ok {
+		return "", &UnknownFieldError{x.t, field}
+	}
+	return x.alias(attrName(sf, x.namer)), nil
+}
+
+// aliases the attribute name an, reusing an alias already made for it
+func (x *expression) alias(an string) string {
+	for k, n := range x.names {
+		if *n == an && strings.HasPrefix(k, "#n") {
+			return k
+		}
+	}
+	k := unusedKey("#n", len(x.names), func(k string) bool { _, ok := x.names[k]; return ok })
+	x.names[k] = &an
+	return k
+}
+
+// A document path names an attribute nested within a map or list, as
+// Address.Zip or Items[0].Name. Each segment of the path is a Go field
+// name, resolved to its attribute name as the field is stored (through
+// its tag or the Encoder's name transformer) while the field holds a
+// struct, and a map key taken as is after that. The path aliases
+// each segment (#n0.#n1, #n2[0].#n3), keeping the list indexes. The
+// fields of a flattened struct are named the same way, Address.Street
+// being the attribute addr_Street of a field tagged "addr_,flatten".
+var pathSegment = regexp.MustCompile(`^([^.\[\]]+)((?:\[[0-9]+\])*)$`)
+
+// aliases the document path p, see pathSegment
+func (x *expression) path(p string) (string, error) {
+	if !strings.ContainsAny(p, ".[") {
+		return x.name(p)
+	}
+	if x.t.Kind() != reflect.Struct {
+		return "", &OnlyStructsSupportedError{x.t.Kind()}
+	}
+	t := x.t
+	segs := strings.Split(p, ".")
+	out := make([]string, 0, len(segs))
+	// the prefix of the fields of a flattened struct, which are attributes
+	// of the item rather than of a map, see flattenedStruct
+	prefix, flat := "", false
+	for _, seg := range segs {
+		m := pathSegment.FindStringSubmatch(seg)
+		if m == nil {
+			return "", &DocumentPathError{p}
+		}
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		an := m[1]
+		switch {
+		case t != nil && t.Kind() == reflect.Struct:
+			sf, ok := t.FieldByName(m[1])
+			if !ok {
+				return "", &UnknownFieldError{t, m[1]}
+			}
+			if ft, pre, ok := flattenedStruct(sf); ok && m[2] == "" {
+				prefix, flat, t = prefix+pre, true, ft
+				continue
+			}
+			// the fields of a flattened struct are named by their prefix
+			if prefix == "" {
+				an = attrName(sf, x.namer)
+			} else {
+				an = prefix + getAttrName(sf)
+			}
+			t = sf.Type
+			prefix, flat = "", false
+		case t != nil && t.Kind() == reflect.Map:
+			t = t.Elem()
+		default:
+			t = nil
+		}
+		for i := 0; i < strings.Count(m[2], "["); i++ {
+			for t != nil && t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+				t = t.Elem()
+			} else {
+				t = nil
+			}
+		}
+		out = append(out, x.alias(an)+m[2])
+	}
+	if flat {
+		return "", &DocumentPathError{p}
+	}
+	return strings.Join(out, "."), nil
+}
+
+// aliases the encoded value v
+func (x *expression) value(v interface{}) (string, error) {
+	av, err := marshalValue(v, x.namer)
+	if err != nil {
+		return "", err
+	}
+	return x.attributeValue(av), nil
+}
+
+// aliases an already encoded value
+func (x *expression) attributeValue(av *dynamodb.AttributeValue) string {
+	k := unusedKey(":v", len(x.values), func(k string) bool { _, ok := x.values[k]; return ok })
+	x.values[k] = av
+	return k
+}
+
+func (x *expression) condition(c Condition) (string, error) {
+	if c.join != "" {
+		return x.join(c.join, c.conds)
+	}
+	switch c.op {
+	case "=", "<>", "<", "<=", ">", ">=", "contains", "attribute_exists", "attribute_not_exists":
+	default:
+		return "", &UnsupportedOperatorError{c.op}
+	}
+	n, err := x.path(c.field)
+	if err != nil {
+		return "", err
+	}
+	if c.op == "attribute_exists" || c.op == "attribute_not_exists" {
+		return c.op + "(" + n + ")", nil
+	}
+	v, err := x.value(c.value)
+	if err != nil {
+		return "", err
+	}
+	if c.op == "contains" {
+		return "contains(" + n + ", " + v + ")", nil
+	}
+	return n + " " + c.op + " " + v, nil
+}
+
+func (x *expression) and(conds []Condition) (string, error) {
+	return x.join("AND", conds)
+}
+
+// joins the expressions of conds with op, parenthesizing those which
+// join others themselves
+func (x *expression) join(op string, conds []Condition) (string, error) {
+	if len(conds) == 0 {
+		return "", &EmptyConditionError{op}
+	}
+	exprs := make([]string, len(conds))
+	for i, c := range conds {
+		e, err := x.condition(c)
+		if err != nil {
+			return "", err
+		}
+		if c.join != "" && len(c.conds) > 1 && len(conds) > 1 {
+			e = "(" + e + ")"
+		}
+		exprs[i] = e
+	}
+	return strings.Join(exprs, " "+op+" "), nil
+}
+
+// the first prefix+n, counting from n, which isn't taken
+func unusedKey(prefix string, n int, taken func(string) bool) string {
+	for {
+		k := prefix + strconv.Itoa(n)
+		if !taken(k) {
+			return k
+		}
+		n++
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScanFilter(t *testing.T) {
+	si, err := ScanInput(Usr{}, Filter("Alias", "=", "bob"), Filter("Id", ">=", "1000"))
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if *si.FilterExpression != "#n0 = :v0 AND #n1 >= :v1" {
+		t.Errorf("failed: filter expression %q", *si.FilterExpression)
+	}
+	if *si.ExpressionAttributeNames["#n0"] != "Alias" || *si.ExpressionAttributeNames["#n1"] != "UserId" {
+		t.Errorf("failed: attribute names %v", si.ExpressionAttributeNames)
+	}
+	if *si.ExpressionAttributeValues[":v0"].S != "bob" || *si.ExpressionAttributeValues[":v1"].S != "1000" {
+		t.Errorf("failed: attribute values %v", si.ExpressionAttributeValues)
+	}
+	if *si.TableName != TableName(reflect.TypeOf(usr0)) {
+		t.Errorf("failed: table name %s", *si.TableName)
+	}
+}
+
+func TestQueryFilter(t *testing.T) {
+	qi, err := QueryOnPartition(CreateKeyMaker(reflect.TypeOf(ses0)), "1000")
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if err := ApplyFilter(qi, &ses0, Filter("Duration", ">", 5)); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if err := ApplyFilter(qi, &ses0, Filter("End", "<", 20)); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if *qi.FilterExpression != "(#n1 > :v1) AND (#n2 < :v2)" {
+		t.Errorf("failed: filter expression %q", *qi.FilterExpression)
+	}
+	if *qi.ExpressionAttributeNames["#name"] != "Usr" || *qi.ExpressionAttributeNames["#n2"] != "End" {
+		t.Errorf("failed: attribute names %v", qi.ExpressionAttributeNames)
+	}
+	if *qi.ExpressionAttributeValues[":v1"].N != "5" || qi.ExpressionAttributeValues[":value"] == nil {
+		t.Errorf("failed: attribute values %v", qi.ExpressionAttributeValues)
+	}
+	if err := ApplyFilter(qi, ses0, Filter("Nope", "=", 1)); err == nil {
+		t.Error("failed: expected error for unknown field")
+	}
+	if err := ApplyFilter(qi, ses0, Filter("End", "~", 1)); err == nil {
+		t.Error("failed: expected error for unknown operator")
+	}
+}
+
+func TestFilterFunctions(t *testing.T) {
+	si, err := ScanInput(Order{},
+		Filter("OrderId", "contains", "2016"),
+		Filter("Items", "contains", "widget"),
+		Filter("Total", "attribute_exists", nil),
+		Filter("CreatedAt", "attribute_not_exists", nil))
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	want := "contains(#n0, :v0) AND contains(#n1, :v1) AND attribute_exists(#n2) AND attribute_not_exists(#n3)"
+	if *si.FilterExpression != want {
+		t.Errorf("failed: filter expression %q", *si.FilterExpression)
+	}
+	if *si.ExpressionAttributeNames["#n1"] != "Items" || *si.ExpressionAttributeNames["#n3"] != "created" {
+		t.Errorf("failed: attribute names %v", si.ExpressionAttributeNames)
+	}
+	// the set element is matched as a string, and presence checks take no value
+	if len(si.ExpressionAttributeValues) != 2 || *si.ExpressionAttributeValues[":v1"].S != "widget" {
+		t.Errorf("failed: attribute values %v", si.ExpressionAttributeValues)
+	}
+
+	si, err = ScanInput(Order{}, Filter("Total", "attribute_exists", nil))
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	// an empty map would be sent as {}, which dynamoDB rejects
+	if si.ExpressionAttributeValues != nil {
+		t.Errorf("failed: attribute values %v for a filter without values", si.ExpressionAttributeValues)
+	}
+}
+
+type Device struct {
+	Id      string `dynaGo:",HASH"`
+	Enabled bool
+	Ports   []int
+	Secret  []byte
+}
+
+func TestFilterValueTypes(t *testing.T) {
+	si, err := ScanInput(Device{},
+		Filter("Enabled", "=", false),
+		Filter("Ports", "contains", 443),
+		Filter("Ports", "=", []int{80, 443}),
+		Filter("Secret", "=", []byte{0x0f}))
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if *si.FilterExpression != "#n0 = :v0 AND contains(#n1, :v1) AND #n1 = :v2 AND #n2 = :v3" {
+		t.Errorf("failed: filter expression %q", *si.FilterExpression)
+	}
+	vs := si.ExpressionAttributeValues
+	if vs[":v0"].BOOL == nil || *vs[":v0"].BOOL {
+		t.Errorf("failed: BOOL value %v", vs[":v0"])
+	}
+	if vs[":v1"].N == nil || *vs[":v1"].N != "443" {
+		t.Errorf("failed: set member value %v", vs[":v1"])
+	}
+	if len(vs[":v2"].NS) != 2 || *vs[":v2"].NS[1] != "443" {
+		t.Errorf("failed: NS value %v", vs[":v2"])
+	}
+	if !reflect.DeepEqual(vs[":v3"].B, []byte{0x0f}) {
+		t.Errorf("failed: B value %v", vs[":v3"])
+	}
+}
+
+func TestFilterDocumentPath(t *testing.T) {
+	type Address struct {
+		Zip string `dynaGo:"zip"`
+	}
+	type Shopper struct {
+		Id   string `dynaGo:",HASH"`
+		Home Address
+		Tags map[string]string
+	}
+	si, err := ScanInput(Shopper{}, Filter("Home.Zip", "=", "02139"), Filter("Tags.vip", "attribute_exists", nil))
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if *si.FilterExpression != "#n0.#n1 = :v0 AND attribute_exists(#n2.#n3)" {
+		t.Errorf("failed: filter expression %q", *si.FilterExpression)
+	}
+	if *si.ExpressionAttributeNames["#n1"] != "zip" || *si.ExpressionAttributeNames["#n3"] != "vip" {
+		t.Errorf("failed: attribute names %v", si.ExpressionAttributeNames)
+	}
+}
+
+func TestConditionExpression(t *testing.T) {
+	u := Usr{Id: "1000", Email: "bob@example.com", Alias: "bob"}
+	ui, err := Update(u).FieldMask("Email").If(Filter("Alias", "=", "bob"), Filter("Origin", "<>", "import")).Input()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if *ui.UpdateExpression != "SET #n0 = :v0" {
+		t.Errorf("failed: update expression %q", *ui.UpdateExpression)
+	}
+	if *ui.ConditionExpression != "#n1 = :v1 AND #n2 <> :v2" {
+		t.Errorf("failed: condition expression %q", *ui.ConditionExpression)
+	}
+	if *ui.ExpressionAttributeNames["#n1"] != "Alias" || *ui.ExpressionAttributeNames["#n2"] != "Origin" {
+		t.Errorf("failed: attribute names %v", ui.ExpressionAttributeNames)
+	}
+	if *ui.ExpressionAttributeValues[":v0"].S != "bob@example.com" || *ui.ExpressionAttributeValues[":v1"].S != "bob" ||
+		*ui.ExpressionAttributeValues[":v2"].S != "import" {
+		t.Errorf("failed: attribute values %v", ui.ExpressionAttributeValues)
+	}
+
+	pi, err := Put(u).If(Or(Filter("Id", "attribute_not_exists", nil),
+		And(Filter("Alias", "=", "bob"), Filter("Email", "attribute_exists", nil)))).Input()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if *pi.ConditionExpression != "attribute_not_exists(#n0) OR (#n1 = :v0 AND attribute_exists(#n2))" {
+		t.Errorf("failed: condition expression %q", *pi.ConditionExpression)
+	}
+	di, err := Delete(u).If(Filter("Email", "attribute_exists", nil)).Input()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if *di.ConditionExpression != "attribute_exists(#n0)" || di.ExpressionAttributeValues != nil {
+		t.Errorf("failed: condition expression %q, values %v", *di.ConditionExpression, di.ExpressionAttributeValues)
+	}
+	if _, err := Delete(u).If(Or()).Input(); err == nil {
+		t.Error("failed: expected EmptyConditionError")
+	}
+}
+
+func TestConditionNameTransformer(t *testing.T) {
+	type Visit struct {
+		Id        string `dynaGo:",HASH"`
+		CreatedAt int64
+		LastSeen  int64
+	}
+	enc := NewEncoder()
+	enc.SetNameTransformer(snakeCase)
+	v := Visit{Id: "v1", CreatedAt: 1, LastSeen: 2}
+	exists := Filter("CreatedAt", "attribute_exists", nil)
+
+	ui, err := enc.Update(v).FieldMask("LastSeen").If(exists).Input()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if *ui.UpdateExpression != "SET #n0 = :v0" || *ui.ConditionExpression != "attribute_exists(#n1)" ||
+		*ui.ExpressionAttributeNames["#n0"] != "last_seen" || *ui.ExpressionAttributeNames["#n1"] != "created_at" {
+		t.Errorf("failed: update %q if %q, names %v", *ui.UpdateExpression, *ui.ConditionExpression, ui.ExpressionAttributeNames)
+	}
+	pi, err := enc.Put(v).If(exists).Input()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if _, ok := pi.Item["created_at"]; !ok || *pi.ExpressionAttributeNames["#n0"] != "created_at" {
+		t.Errorf("failed: put item %v, names %v", pi.Item, pi.ExpressionAttributeNames)
+	}
+	di, err := enc.Delete(v).If(exists).Input()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if *di.ExpressionAttributeNames["#n0"] != "created_at" {
+		t.Errorf("failed: delete names %v", di.ExpressionAttributeNames)
+	}
+	si, err := enc.Scan(v).Filter(exists).Input()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if *si.ExpressionAttributeNames["#n0"] != "created_at" {
+		t.Errorf("failed: scan names %v", si.ExpressionAttributeNames)
+	}
+}
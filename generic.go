@@ -0,0 +1,141 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package dynaGo
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// The typed helpers below wrap the reflection based functions for
+// toolchains with generics, replacing interface{} arguments and pointer
+// juggling with a type parameter. T is a tagged struct type.
+
+// MarshalT is Marshal for a value of type T, returning encoding failures
+// as an error instead of panicking.
+func MarshalT[T any](v T) (*dynamodb.PutItemInput, error) {
+	return std.marshal(v)
+}
+
+// UnmarshalT decodes item into a new T
+func UnmarshalT[T any](item map[string]*dynamodb.AttributeValue) (T, error) {
+	var v T
+	err := Unmarshal(item, &v)
+	return v, err
+}
+
+// ScanAllT reads every item of T's table, see ScanAll.
+func ScanAllT[T any](svc dynamodbiface.DynamoDBAPI) ([]T, error) {
+	var vs []T
+	err := ScanAll(svc, &vs)
+	return vs, err
+}
+
+// Iterator streams the items of a scan or query as values of T, fetching
+// and decoding a page at a time, so memory is bounded by the page size
+// rather than the table. Use it as
+//
+//	it := NewScanIterator[Usr](svc, Scan(Usr{}))
+//	for it.Next() {
+//		u := it.Item()
+//		...
+//	}
+//	if err := it.Err(); err != nil { ... }
+type Iterator[T any] struct {
+	ctx   context.Context
+	fetch func(ctx context.Context, start map[string]*dynamodb.AttributeValue) (items []map[string]*dynamodb.AttributeValue, last map[string]*dynamodb.AttributeValue, err error)
+	start map[string]*dynamodb.AttributeValue
+	done  bool
+	items []map[string]*dynamodb.AttributeValue
+	item  T
+	err   error
+}
+
+// NewScanIterator iterates over the items the scan b reads, from its
+// StartKey (if any) to the end of the table. A Limit set on b bounds the
+// size of each page, not the number of items iterated.
+func NewScanIterator[T any](svc dynamodbiface.DynamoDBAPI, b *ScanBuilder) *Iterator[T] {
+	in, err := b.Input()
+	it := &Iterator[T]{ctx: context.Background(), err: err}
+	if err != nil {
+		return it
+	}
+	it.start = in.ExclusiveStartKey
+	it.fetch = func(ctx context.Context, start map[string]*dynamodb.AttributeValue) ([]map[string]*dynamodb.AttributeValue, map[string]*dynamodb.AttributeValue, error) {
+		c := *in
+		c.ExclusiveStartKey = start
+		resp, err := svc.ScanWithContext(ctx, &c)
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp.Items, resp.LastEvaluatedKey, nil
+	}
+	return it
+}
+
+// NewQueryIterator iterates over every item the query b matches.
+func NewQueryIterator[T any](svc dynamodbiface.DynamoDBAPI, b *QueryBuilder) *Iterator[T] {
+	in, err := b.Input()
+	it := &Iterator[T]{ctx: context.Background(), err: err}
+	if err != nil {
+		return it
+	}
+	it.fetch = func(ctx context.Context, start map[string]*dynamodb.AttributeValue) ([]map[string]*dynamodb.AttributeValue, map[string]*dynamodb.AttributeValue, error) {
+		c := *in
+		c.ExclusiveStartKey = start
+		resp, err := svc.QueryWithContext(ctx, &c)
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp.Items, resp.LastEvaluatedKey, nil
+	}
+	return it
+}
+
+// WithContext fetches the pages it has yet to read with ctx, so
+// cancelling ctx stops the iteration with ctx's error.
+func (it *Iterator[T]) WithContext(ctx context.Context) *Iterator[T] {
+	it.ctx = ctx
+	return it
+}
+
+// Next advances to the next item, fetching the next page when the last is
+// used up. It reports false at the end of the items, or on a failure
+// which Err then returns.
+func (it *Iterator[T]) Next() bool {
+	for len(it.items) == 0 {
+		if it.err != nil || it.done {
+			return false
+		}
+		if it.err = it.ctx.Err(); it.err != nil {
+			return false
+		}
+		it.items, it.start, it.err = it.fetch(it.ctx, it.start)
+		it.done = len(it.start) == 0
+	}
+	var v T
+	if it.err = Unmarshal(it.items[0], &v); it.err != nil {
+		return false
+	}
+	it.items = it.items[1:]
+	it.item = v
+	return true
+}
+
+// Item is the item Next advanced to.
+func (it *Iterator[T]) Item() T {
+	return it.item
+}
+
+// Err is the failure which ended the iteration, nil once all the items
+// are read.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
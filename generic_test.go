@@ -0,0 +1,94 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package dynaGo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestMarshalUnmarshalT(t *testing.T) {
+	pi, err := MarshalT(usr0)
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	u, err := UnmarshalT[Usr](pi.Item)
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if !reflect.DeepEqual(u, usr0) {
+		t.Errorf("failed: round trip\n\t%+v\nwant\n\t%+v", u, usr0)
+	}
+	if _, err := MarshalT(42); err == nil {
+		t.Error("failed: expected OnlyStructsSupportedError")
+	}
+}
+
+func TestScanAllT(t *testing.T) {
+	svc := &stubDynamo{scanOut: []*dynamodb.ScanOutput{
+		{
+			Items:            []map[string]*dynamodb.AttributeValue{Marshal(usr0).Item},
+			LastEvaluatedKey: map[string]*dynamodb.AttributeValue{"UserId": {S: aws.String("1000")}},
+		},
+		{
+			Items: []map[string]*dynamodb.AttributeValue{Marshal(usr1).Item},
+		},
+	}}
+	us, err := ScanAllT[Usr](svc)
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if !reflect.DeepEqual(us, []Usr{usr0, usr1}) {
+		t.Errorf("failed: scanned %+v", us)
+	}
+	if len(svc.scanIn) != 2 || *svc.scanIn[1].ExclusiveStartKey["UserId"].S != "1000" {
+		t.Errorf("failed: second page not requested from LastEvaluatedKey")
+	}
+	ps, err := ScanAllT[*Usr](&stubDynamo{scanOut: []*dynamodb.ScanOutput{
+		{Items: []map[string]*dynamodb.AttributeValue{Marshal(usr1).Item}},
+	}})
+	if err != nil || len(ps) != 1 || !reflect.DeepEqual(*ps[0], usr1) {
+		t.Errorf("failed: scanned pointers %v %v", ps, err)
+	}
+}
+
+func TestScanIterator(t *testing.T) {
+	s := &stubDynamo{scanOut: []*dynamodb.ScanOutput{
+		{
+			Items:            []map[string]*dynamodb.AttributeValue{Marshal(usr0).Item},
+			LastEvaluatedKey: map[string]*dynamodb.AttributeValue{"UserId": {S: aws.String(usr0.Id)}},
+		},
+		{Items: []map[string]*dynamodb.AttributeValue{Marshal(usr1).Item}},
+	}}
+	it := NewScanIterator[Usr](s, Scan(Usr{}).Limit(1))
+	var us []Usr
+	for it.Next() {
+		us = append(us, it.Item())
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if !reflect.DeepEqual(us, []Usr{usr0, usr1}) {
+		t.Errorf("failed: iterated %+v", us)
+	}
+	if len(s.scanIn) != 2 || s.scanIn[1].ExclusiveStartKey == nil || *s.scanIn[1].Limit != 1 {
+		t.Errorf("failed: scan inputs %v", s.scanIn)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	it = NewScanIterator[Usr](&stubDynamo{}, Scan(Usr{})).WithContext(ctx)
+	if it.Next() || !errors.Is(it.Err(), context.Canceled) {
+		t.Errorf("failed: expected cancelled iteration, got %v", it.Err())
+	}
+}
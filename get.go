@@ -0,0 +1,135 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// GetBuilder assembles a dynamodb.GetItemInput for the item with the key
+// of a struct.
+type GetBuilder struct {
+	enc     *Encoder
+	i       interface{}
+	project []string
+	rcc     string
+}
+
+// Get begins a GetItemInput for the item with the key of i, a struct or
+// pointer to struct with its key fields set. Decode the Item of the
+// output into a struct of the same type with Unmarshal, or send it and
+// decode the item found with Load.
+func Get(i interface{}) *GetBuilder {
+	return std.Get(i)
+}
+
+// Project reads only the attributes of the named Go fields, setting the
+// ProjectionExpression so the rest of the item isn't read (reads are
+// billed by the size of the attributes returned). A field may be given
+// as a document path (Address.Zip) to read only part of its attribute.
+func (b *GetBuilder) Project(fields ...string) *GetBuilder {
+	b.project = append(b.project, fields...)
+	return b
+}
+
+// ReturnConsumedCapacity asks dynamoDB to report the capacity the read
+// consumes, TOTAL or by INDEXES, or NONE (the default).
+func (b *GetBuilder) ReturnConsumedCapacity(rcc string) *GetBuilder {
+	b.rcc = rcc
+	return b
+}
+
+// Input builds the GetItemInput
+func (b *GetBuilder) Input() (*dynamodb.GetItemInput, error) {
+	if err := checkConsumedCapacity(b.rcc); err != nil {
+		return nil, err
+	}
+	key, err := b.enc.KeyMap(b.i)
+	if err != nil {
+		return nil, err
+	}
+	t := reflect.TypeOf(b.i)
+	tn, err := b.enc.tableName(t)
+	if err != nil {
+		return nil, err
+	}
+	gi := &dynamodb.GetItemInput{
+		TableName: &tn,
+		Key:       key,
+	}
+	if len(b.project) > 0 {
+		x := newExpression(t, b.enc.namer, nil, nil)
+		ns := make([]string, len(b.project))
+		for n, f := range b.project {
+			if ns[n], err = x.path(f); err != nil {
+				return nil, err
+			}
+		}
+		pe := strings.Join(ns, ", ")
+		gi.ProjectionExpression = &pe
+		gi.ExpressionAttributeNames = x.names
+	}
+	if b.rcc != "" {
+		gi.ReturnConsumedCapacity = &b.rcc
+	}
+	return gi, nil
+}
+
+// Load reads the item with the key of i, a pointer to struct, into i and
+// reports whether it was found. When fields are given only their
+// attributes are read (see Project) and decoded, the other fields of i
+// are left as they were.
+func Load(svc dynamodbiface.DynamoDBAPI, i interface{}, fields ...string) (bool, error) {
+	return Get(i).Project(fields...).load(svc, stdDecoder, nil)
+}
+
+// LoadCapacity is Load which also returns the capacity the read consumed.
+func LoadCapacity(svc dynamodbiface.DynamoDBAPI, i interface{}, fields ...string) (bool, Capacity, error) {
+	c := make(Capacity)
+	ok, err := Get(i).Project(fields...).ReturnConsumedCapacity(dynamodb.ReturnConsumedCapacityTotal).load(svc, stdDecoder, c)
+	return ok, c, err
+}
+
+// Load reads the item with the key of the struct the builder was begun
+// with, a pointer to struct, into it with dec, as the package level Load
+// does. Give dec the name transformer of the Encoder the builder was
+// begun from; a nil dec decodes as Unmarshal does.
+func (b *GetBuilder) Load(svc dynamodbiface.DynamoDBAPI, dec *Decoder) (bool, error) {
+	if dec == nil {
+		dec = stdDecoder
+	}
+	return b.load(svc, dec, nil)
+}
+
+func (b *GetBuilder) load(svc dynamodbiface.DynamoDBAPI, dec *Decoder, c Capacity) (bool, error) {
+	gi, err := b.Input()
+	if err != nil {
+		return false, err
+	}
+	resp, err := svc.GetItem(gi)
+	if err != nil {
+		return false, err
+	}
+	if c != nil {
+		c.add(resp.ConsumedCapacity)
+	}
+	if resp.Item == nil {
+		return false, nil
+	}
+	item := resp.Item
+	if len(b.project) > 0 {
+		item = make(map[string]*dynamodb.AttributeValue, len(gi.ExpressionAttributeNames))
+		for _, an := range gi.ExpressionAttributeNames {
+			if av, ok := resp.Item[*an]; ok {
+				item[*an] = av
+			}
+		}
+	}
+	return true, dec.Unmarshal(item, b.i)
+}
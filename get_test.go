@@ -0,0 +1,120 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestLoadProjection(t *testing.T) {
+	stored := Order{Customer: "c1", OrderId: "o1", CreatedAt: 10, Total: 25, Items: []string{"a"}}
+	tn := TableName(reflect.TypeOf(stored))
+	svc := &stubDynamo{items: map[string][]map[string]*dynamodb.AttributeValue{
+		tn: {Marshal(stored).Item},
+	}}
+
+	o := Order{Customer: "c1", OrderId: "o1"}
+	found, err := Load(svc, &o, "Total", "CreatedAt")
+	if err != nil || !found {
+		t.Fatalf("failed: %v %s", found, err)
+	}
+	gi := svc.getIn[0]
+	if *gi.ProjectionExpression != "#n0, #n1" ||
+		*gi.ExpressionAttributeNames["#n0"] != "Total" || *gi.ExpressionAttributeNames["#n1"] != "created" {
+		t.Errorf("failed: projection %s %v", *gi.ProjectionExpression, gi.ExpressionAttributeNames)
+	}
+	if o.Total != 25 || o.CreatedAt != 10 || o.Items != nil {
+		t.Errorf("failed: decoded %+v", o)
+	}
+
+	o = Order{Customer: "c1", OrderId: "o1"}
+	if found, err := Load(svc, &o); err != nil || !found || len(o.Items) != 1 {
+		t.Errorf("failed: full load %+v %v %v", o, found, err)
+	}
+	if gi := svc.getIn[1]; gi.ProjectionExpression != nil {
+		t.Errorf("failed: unprojected get has projection %s", *gi.ProjectionExpression)
+	}
+	if found, err := Load(svc, &Order{Customer: "c2", OrderId: "o1"}, "Total"); err != nil || found {
+		t.Errorf("failed: missing item found %v %v", found, err)
+	}
+	if _, err := Load(svc, &o, "Nope"); err == nil {
+		t.Error("failed: expected error projecting unknown field")
+	}
+}
+
+func TestProjectDocumentPath(t *testing.T) {
+	type Geo struct {
+		Lat float64 `dynaGo:"lat"`
+	}
+	type Address struct {
+		Zip string `dynaGo:"zip"`
+		Geo Geo
+	}
+	type Line struct {
+		Name string
+	}
+	type Customer struct {
+		Id    string  `dynaGo:",HASH"`
+		Addr  Address `dynaGo:"address"`
+		Lines []Line  `dynaGo:",list"`
+	}
+	in, err := Get(Customer{Id: "c1"}).Project("Addr.Zip", "Addr.Geo.Lat", "Lines[0].Name").Input()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if pe := *in.ProjectionExpression; pe != "#n0.#n1, #n0.#n2.#n3, #n4[0].#n5" {
+		t.Errorf("failed: projection %s", pe)
+	}
+	want := []string{"address", "zip", "Geo", "lat", "Lines", "Name"}
+	for n, an := range want {
+		if got := in.ExpressionAttributeNames["#n"+strconv.Itoa(n)]; got == nil || *got != an {
+			t.Errorf("failed: #n%d names %v, want %s", n, got, an)
+		}
+	}
+	if _, err := Get(Customer{Id: "c1"}).Project("Addr.Nope").Input(); err == nil {
+		t.Error("failed: expected error for unknown nested field")
+	}
+	if _, err := Get(Customer{Id: "c1"}).Project("Lines[x]").Input(); err == nil {
+		t.Error("failed: expected error for invalid path")
+	}
+}
+
+func TestEncoderGetLoad(t *testing.T) {
+	type Visit struct {
+		VisitId   string `dynaGo:",HASH"`
+		CreatedAt int64
+		LastSeen  int64
+	}
+	enc := NewEncoder()
+	enc.SetNameTransformer(snakeCase)
+	dec := NewDecoder()
+	dec.SetNameTransformer(snakeCase)
+	stored := Visit{VisitId: "v1", CreatedAt: 10, LastSeen: 20}
+	svc := &stubDynamo{items: map[string][]map[string]*dynamodb.AttributeValue{
+		enc.TableName(reflect.TypeOf(stored)): {enc.Marshal(stored).Item},
+	}}
+
+	v := Visit{VisitId: "v1"}
+	found, err := enc.Get(&v).Project("CreatedAt").Load(svc, dec)
+	if err != nil || !found {
+		t.Fatalf("failed: %v %s", found, err)
+	}
+	if gi := svc.getIn[0]; *gi.ExpressionAttributeNames["#n0"] != "created_at" {
+		t.Errorf("failed: projection names %v", gi.ExpressionAttributeNames)
+	}
+	if v.CreatedAt != 10 || v.LastSeen != 0 {
+		t.Errorf("failed: decoded %+v", v)
+	}
+
+	// the package level Decoder doesn't know the transformed names
+	v = Visit{VisitId: "v1"}
+	if found, err := enc.Get(&v).Load(svc, nil); err != nil || !found || v.CreatedAt != 0 {
+		t.Errorf("failed: loaded %+v %v %v", v, found, err)
+	}
+}
@@ -10,8 +10,10 @@ import (
 	"reflect"
 	"runtime"
 	"strconv"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
 // This part of the package is somewhat ad-hoc and disorganized.
@@ -51,7 +53,7 @@ func CreateKeyMaker(rt reflect.Type) KeyMaker {
 	//partition key, panics if not found
 	pki := getPartitionKey(t)
 	pF := func(kv interface{}) (string, dynamodb.AttributeValue, error) {
-		return getKeynameAndAttribute(t, pki, kv)
+		return getKeynameAndAttribute(t, pki, kv, nil)
 	}
 
 	//range key may not exist
@@ -75,7 +77,7 @@ func CreateKeyMaker(rt reflect.Type) KeyMaker {
 		}
 	}
 	rF := func(rk interface{}) (string, dynamodb.AttributeValue, error) {
-		return getKeynameAndAttribute(t, rki, rk)
+		return getKeynameAndAttribute(t, rki, rk, nil)
 	}
 
 	return func(ks ...interface{}) (key, error) {
@@ -101,6 +103,168 @@ func CreateKeyMaker(rt reflect.Type) KeyMaker {
 	}
 }
 
+// KeyMap returns the primary key of the struct i as the attribute map
+// used as the Key of GetItem, UpdateItem and DeleteItem inputs. Unlike a
+// KeyMaker, the HASH (and RANGE, if the type has one) values are read
+// from i itself, following keys nested in tagged structs and pointers.
+func KeyMap(i interface{}) (map[string]*dynamodb.AttributeValue, error) {
+	return keyMap(i, nil)
+}
+
+func keyMap(i interface{}, namer func(string) string) (m map[string]*dynamodb.AttributeValue, err error) {
+	defer recoverError(&err)
+	v := reflect.ValueOf(i)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, &NilKeyValueError{v.Type()}
+		}
+		v = v.Elem()
+	}
+	t := v.Type()
+	if t.Kind() != reflect.Struct {
+		return nil, &OnlyStructsSupportedError{t.Kind()}
+	}
+	m = make(map[string]*dynamodb.AttributeValue)
+	pk, pv, err := keyAttribute(v, getPartitionKey(t), namer)
+	if err != nil {
+		return nil, err
+	}
+	m[pk] = &pv
+	if rki, rerr := getRangeKey(t); rerr == nil {
+		rk, rv, err := keyAttribute(v, rki, namer)
+		if err != nil {
+			return nil, err
+		}
+		m[rk] = &rv
+	}
+	return m, nil
+}
+
+// UnmarshalKey is the inverse of KeyMap, decoding the key attributes of
+// key (such as the LastEvaluatedKey of a page of results) into the HASH
+// and RANGE fields of the struct i points to. Other fields of i are left
+// as they were, and other attributes of key (the keys of an index) are
+// ignored, so i may be a struct of just the table's key fields, making a
+// typed cursor. A key attribute absent from key is a
+// MissingKeyAttributeError.
+func UnmarshalKey(key map[string]*dynamodb.AttributeValue, i interface{}) (err error) {
+	defer recoverError(&err)
+	rv := reflect.ValueOf(i)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return &InvalidDecodeError{reflect.TypeOf(i)}
+	}
+	ev := rv.Elem()
+	t := ev.Type()
+	if t.Kind() != reflect.Struct {
+		return &OnlyStructsSupportedError{t.Kind()}
+	}
+	keys := [][]int{getPartitionKey(t)}
+	if rki, err := getRangeKey(t); err == nil {
+		keys = append(keys, rki)
+	}
+	fields := make([]field, len(keys))
+	for n, ki := range keys {
+		// a nested key is decoded as its leaf field, and named and
+		// reached from the root field holding it, as KeyMap encodes it
+		f := newField(t.FieldByIndex(ki), nil)
+		f.name, f.index = attrName(t.Field(ki[0]), nil), ki
+		fields[n] = f
+		if av, ok := key[fields[n].name]; !ok || isNull(av) {
+			return &MissingKeyAttributeError{t, fields[n].name}
+		}
+	}
+	stdDecoder.decodeFields(key, ev, fields)
+	return nil
+}
+
+// Exists reports whether the item with the key of the struct key is
+// present in its table. The GetItem projects only the partition key
+// attribute, so a hit reads as little of the item as possible.
+func Exists(svc dynamodbiface.DynamoDBAPI, key interface{}) (bool, error) {
+	km, err := KeyMap(key)
+	if err != nil {
+		return false, err
+	}
+	t := reflect.Indirect(reflect.ValueOf(key)).Type()
+	tn, err := std.tableName(t)
+	if err != nil {
+		return false, err
+	}
+	pkn := getAttrName(t.Field(getPartitionKey(t)[0]))
+	pe := "#k"
+	resp, err := svc.GetItem(&dynamodb.GetItemInput{
+		TableName:                &tn,
+		Key:                      km,
+		ProjectionExpression:     &pe,
+		ExpressionAttributeNames: map[string]*string{pe: &pkn},
+	})
+	if err != nil {
+		return false, err
+	}
+	return len(resp.Item) > 0, nil
+}
+
+// A key may be composed of other fields of its struct, their values
+// joined by a separator ("#" unless set with sep=),
+//
+//	PK     string `dynaGo:",HASH,compose=Tenant:Id"`
+//	Tenant string
+//	Id     int
+//
+// stores PK as "acme#123" whatever the PK field holds, so the table has
+// a single string key while Tenant and Id are still stored (and decoded)
+// as attributes of their own. The composed field must be a string, and
+// decodes to the stored key.
+//
+// composedKey returns the value of the field sf of the struct v, if it
+// is composed or sharded (see shardedKey).
+func composedKey(v reflect.Value, sf reflect.StructField) (reflect.Value, bool) {
+	_, o := parseTag(sf.Tag.Get("dynaGo"))
+	cs := o.Values("compose")
+	if len(cs) == 0 {
+		return shardedKey(v, sf, reflect.Value{})
+	}
+	if sf.Type.Kind() != reflect.String {
+		panic(&TagOptionError{sf.Name, "compose=" + cs[0]})
+	}
+	sep := "#"
+	if ss := o.Values("sep"); len(ss) > 0 {
+		sep = ss[0]
+	}
+	names := strings.Split(cs[0], ":")
+	parts := make([]string, len(names))
+	for n, name := range names {
+		pf, ok := v.Type().FieldByName(name)
+		if !ok {
+			panic(&UnknownFieldError{v.Type(), name})
+		}
+		pv, ok := fieldByIndex(v, pf.Index)
+		if !ok {
+			pv = reflect.Zero(pf.Type)
+		}
+		parts[n] = valueEncoder(pf.Type)(nil, name, pv)
+	}
+	return shardedKey(v, sf, reflect.ValueOf(strings.Join(parts, sep)).Convert(sf.Type))
+}
+
+// reads the key value found at the field index path i of v
+func keyAttribute(v reflect.Value, i []int, namer func(string) string) (string, dynamodb.AttributeValue, error) {
+	if cv, ok := composedKey(v, v.Type().Field(i[0])); ok {
+		return getKeynameAndAttribute(v.Type(), i, cv.String(), namer)
+	}
+	kv := v
+	for _, n := range i {
+		if kv.Kind() == reflect.Ptr {
+			if kv.IsNil() {
+				return "", dynamodb.AttributeValue{}, &NilKeyValueError{v.Type()}
+			}
+			kv = kv.Elem()
+		}
+		kv = kv.Field(n)
+	}
+	return getKeynameAndAttribute(v.Type(), i, kv.Interface(), namer)
+}
+
 func GetItemInput(km KeyMaker, kv ...interface{}) (*dynamodb.GetItemInput, error) {
 	k, err := km(kv...)
 	if err != nil {
@@ -145,6 +309,65 @@ func QueryOnPartition(km KeyMaker, kv interface{}) (*dynamodb.QueryInput, error)
 	}, nil
 }
 
+// Count returns the number of items in the partition kv of the table
+// described by km, without reading the items themselves. The query built
+// by QueryOnPartition is issued with Select COUNT and followed through
+// every page, summing the counts.
+func Count(svc dynamodbiface.DynamoDBAPI, km KeyMaker, kv interface{}) (int64, error) {
+	qi, err := QueryOnPartition(km, kv)
+	if err != nil {
+		return 0, err
+	}
+	sel := dynamodb.SelectCount
+	qi.Select = &sel
+	var n int64
+	for {
+		resp, err := svc.Query(qi)
+		if err != nil {
+			return 0, err
+		}
+		if resp.Count != nil {
+			n += *resp.Count
+		}
+		if len(resp.LastEvaluatedKey) == 0 {
+			return n, nil
+		}
+		qi.ExclusiveStartKey = resp.LastEvaluatedKey
+	}
+}
+
+// PartitionKey returns the field of the struct type t (or pointer to
+// struct type) tagged HASH, and false when there is none. The field may
+// itself be a struct, whose own HASH holds the value of the key.
+func PartitionKey(t reflect.Type) (reflect.StructField, bool) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return reflect.StructField{}, false
+	}
+	for n := 0; n < t.NumField(); n++ {
+		sf := t.Field(n)
+		if _, o := parseTag(sf.Tag.Get("dynaGo")); o.Contains(dynamodb.KeyTypeHash) {
+			return sf, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// KeyType returns dynamodb.KeyTypeHash or dynamodb.KeyTypeRange for a
+// field tagged as part of the table's primary key, and a
+// KeyTypeNotFoundError for any other field.
+func KeyType(f reflect.StructField) (string, error) {
+	return getKeyType(f, reflect.Zero(f.Type))
+}
+
+// reports whether a field of t is tagged HASH, without pursuing it
+func hasPartitionKey(t reflect.Type) bool {
+	_, ok := PartitionKey(t)
+	return ok
+}
+
 // depth-first pursuit of a partition key through structs marked HASH
 // if a string is not found at a leaf, this method will panic.
 func getPartitionKey(t reflect.Type) []int {
@@ -184,16 +407,36 @@ func getKeyAttributePath(t reflect.Type, kt string) []int {
 		switch f.Type.Kind() {
 		case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 			return []int{n}
+		case reflect.Slice, reflect.Array:
+			if isBytes(f.Type) {
+				return []int{n}
+			}
 		case reflect.Ptr:
 			return append([]int{n}, getKeyAttributePath(f.Type.Elem(), dynamodb.KeyTypeHash)...)
 		case reflect.Struct:
 			return append([]int{n}, getKeyAttributePath(f.Type, dynamodb.KeyTypeHash)...)
 		}
 	}
-	panic(&MissingKeyError{t, kt})
+	panic(missingKeyError(t, kt))
 }
 
-func getKeynameAndAttribute(t reflect.Type, i []int, k interface{}) (kn string, ka dynamodb.AttributeValue, err error) {
+// the MissingKeyError for the kt key of t, naming the RANGE field of t
+// when it is the HASH which is missing
+func missingKeyError(t reflect.Type, kt string) *MissingKeyError {
+	e := &MissingKeyError{Type: t, KeyType: kt}
+	if kt != dynamodb.KeyTypeHash {
+		return e
+	}
+	for n := 0; n < t.NumField(); n++ {
+		if _, o := parseTag(t.Field(n).Tag.Get("dynaGo")); o.Contains(dynamodb.KeyTypeRange) {
+			e.RangeField = t.Field(n).Name
+			break
+		}
+	}
+	return e
+}
+
+func getKeynameAndAttribute(t reflect.Type, i []int, k interface{}, namer func(string) string) (kn string, ka dynamodb.AttributeValue, err error) {
 	//value from leaf
 	sf := t.FieldByIndex(i)
 	ka, err = createAttribute(sf, k)
@@ -202,7 +445,7 @@ func getKeynameAndAttribute(t reflect.Type, i []int, k interface{}) (kn string,
 	}
 	//name from root
 	rootkf := t.Field(i[0])
-	kn = getAttrName(rootkf)
+	kn = attrName(rootkf, namer)
 	return
 }
 
@@ -224,8 +467,21 @@ func createAttribute(sf reflect.StructField, k interface{}) (ka dynamodb.Attribu
 			err = &KeyValueOfIncorrectType{reflect.Int, v.Kind()}
 			return
 		}
+		if pad, ok := stringInt(sf); ok {
+			s := formatStringInt(v.Int(), pad)
+			ka = dynamodb.AttributeValue{S: &s}
+			return
+		}
 		s := strconv.FormatInt(v.Int(), 10)
 		ka = dynamodb.AttributeValue{N: &s}
+	case reflect.Slice, reflect.Array:
+		v := reflect.ValueOf(k)
+		if !isBytes(sf.Type) || v.Type() != sf.Type {
+			panic(&UnsupportedKeyKindError{sf.Type.Kind()})
+		}
+		b := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(b), v)
+		ka = dynamodb.AttributeValue{B: b}
 	default:
 		panic(&UnsupportedKeyKindError{sf.Type.Kind()})
 	}
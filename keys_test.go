@@ -0,0 +1,196 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestExists(t *testing.T) {
+	svc := &stubDynamo{items: map[string][]map[string]*dynamodb.AttributeValue{
+		TableName(reflect.TypeOf(usr0)): {Marshal(usr0).Item},
+	}}
+	if ok, err := Exists(svc, Usr{Id: "1000"}); err != nil || !ok {
+		t.Errorf("failed: expected hit, got %v %v", ok, err)
+	}
+	if ok, err := Exists(svc, &Usr{Id: "3000"}); err != nil || ok {
+		t.Errorf("failed: expected miss, got %v %v", ok, err)
+	}
+	in := svc.getIn[0]
+	if *in.ProjectionExpression != "#k" || *in.ExpressionAttributeNames["#k"] != "UserId" {
+		t.Errorf("failed: projection %s %v", *in.ProjectionExpression, in.ExpressionAttributeNames)
+	}
+}
+
+func TestCount(t *testing.T) {
+	svc := &stubDynamo{queryOut: []*dynamodb.QueryOutput{
+		{
+			Count:            aws.Int64(3),
+			LastEvaluatedKey: map[string]*dynamodb.AttributeValue{"Usr": {S: aws.String("1000")}},
+		},
+		{Count: aws.Int64(2)},
+	}}
+	n, err := Count(svc, CreateKeyMaker(reflect.TypeOf(ses0)), "1000")
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if n != 5 {
+		t.Errorf("failed: counted %d, want 5", n)
+	}
+	if len(svc.queryIn) != 2 || *svc.queryIn[0].Select != dynamodb.SelectCount {
+		t.Fatalf("failed: queries %v", svc.queryIn)
+	}
+	if svc.queryIn[0].ExclusiveStartKey != nil || *svc.queryIn[1].ExclusiveStartKey["Usr"].S != "1000" {
+		t.Errorf("failed: second page not requested from LastEvaluatedKey")
+	}
+}
+
+func TestPartitionKeyAndKeyType(t *testing.T) {
+	pk, ok := PartitionKey(reflect.TypeOf(&Order{}))
+	if !ok || pk.Name != "Customer" {
+		t.Errorf("failed: partition key %v %v", pk.Name, ok)
+	}
+	if _, ok := PartitionKey(reflect.TypeOf(Money{})); ok {
+		t.Error("failed: found a partition key on a keyless struct")
+	}
+	if _, ok := PartitionKey(reflect.TypeOf(42)); ok {
+		t.Error("failed: found a partition key on an int")
+	}
+	ot := reflect.TypeOf(Order{})
+	for field, want := range map[string]string{"Customer": "HASH", "OrderId": "RANGE"} {
+		sf, _ := ot.FieldByName(field)
+		if kt, err := KeyType(sf); err != nil || kt != want {
+			t.Errorf("failed: key type of %s %q %v", field, kt, err)
+		}
+	}
+	// an index key is not part of the table's key
+	for _, field := range []string{"CreatedAt", "Total"} {
+		sf, _ := ot.FieldByName(field)
+		if _, err := KeyType(sf); err == nil {
+			t.Errorf("failed: expected error for %s", field)
+		}
+	}
+}
+
+func TestComposedPartitionKey(t *testing.T) {
+	type Seat struct {
+		PK     string `dynaGo:",HASH,compose=Tenant:Id"`
+		Tenant string
+		Id     int
+		Holder string
+	}
+	s := Seat{Tenant: "acme", Id: 123, Holder: "bo"}
+	item := Marshal(s).Item
+	if item["PK"] == nil || *item["PK"].S != "acme#123" {
+		t.Errorf("failed: composed key %v", item["PK"])
+	}
+	if *item["Tenant"].S != "acme" || *item["Id"].N != "123" {
+		t.Errorf("failed: key parts %v", item)
+	}
+	km, err := KeyMap(s)
+	if err != nil || len(km) != 1 || *km["PK"].S != "acme#123" {
+		t.Errorf("failed: key map %v %v", km, err)
+	}
+	ct, err := BuildCreateTableInput(s, 1, 1)
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if ks := keySchemaString(ct.KeySchema); ks != "PK:HASH" ||
+		len(ct.AttributeDefinitions) != 1 || *ct.AttributeDefinitions[0].AttributeType != dynamodb.ScalarAttributeTypeS {
+		t.Errorf("failed: key schema %s %v", ks, ct.AttributeDefinitions)
+	}
+	var out Seat
+	if err := Unmarshal(item, &out); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if out.PK != "acme#123" || out.Tenant != "acme" || out.Id != 123 {
+		t.Errorf("failed: decoded %+v", out)
+	}
+
+	type Slotted struct {
+		PK   string `dynaGo:",HASH,compose=Zone:Slot,sep=|"`
+		Zone string
+		Slot string
+	}
+	if item := Marshal(Slotted{Zone: "z", Slot: "9"}).Item; *item["PK"].S != "z|9" {
+		t.Errorf("failed: separated key %v", item["PK"])
+	}
+	type Unknown struct {
+		PK string `dynaGo:",HASH,compose=Nope"`
+	}
+	if _, err := KeyMap(Unknown{}); err == nil {
+		t.Error("failed: expected error composing an unknown field")
+	}
+}
+
+func TestUnmarshalKey(t *testing.T) {
+	type MessageKey struct {
+		SessId    string `dynaGo:"SessionId,HASH"`
+		Timestamp int64  `dynaGo:",RANGE"`
+	}
+	lek, err := KeyMap(Message{SessId: "abc", Timestamp: 1234, Body: "ignored"})
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	// a page read through an index carries the index key too
+	lek["Body"] = &dynamodb.AttributeValue{S: aws.String("hi")}
+	var k MessageKey
+	if err := UnmarshalKey(lek, &k); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if k != (MessageKey{"abc", 1234}) {
+		t.Errorf("failed: decoded %+v", k)
+	}
+	m := Message{Body: "kept"}
+	if err := UnmarshalKey(lek, &m); err != nil || m.SessId != "abc" || m.Timestamp != 1234 || m.Body != "kept" {
+		t.Errorf("failed: decoded %+v %v", m, err)
+	}
+
+	delete(lek, "Timestamp")
+	var mka *MissingKeyAttributeError
+	if err := UnmarshalKey(lek, &k); !errors.As(err, &mka) || mka.Attribute != "Timestamp" {
+		t.Errorf("failed: expected MissingKeyAttributeError, got %v", err)
+	}
+}
+
+func TestUnmarshalNestedKey(t *testing.T) {
+	type Inner struct {
+		Id string `dynaGo:",HASH"`
+	}
+	type Outer struct {
+		Name  string
+		Owner Inner `dynaGo:"owner,HASH"`
+	}
+	type PtrOuter struct {
+		Name  string
+		Owner *Inner `dynaGo:"owner,HASH"`
+	}
+	key, err := KeyMap(Outer{Name: "n", Owner: Inner{"x"}})
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if len(key) != 1 || key["owner"] == nil || *key["owner"].S != "x" {
+		t.Fatalf("failed: key %v", key)
+	}
+	var o Outer
+	if err := UnmarshalKey(key, &o); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if o != (Outer{Owner: Inner{"x"}}) {
+		t.Errorf("failed: decoded %+v", o)
+	}
+	var p PtrOuter
+	if err := UnmarshalKey(key, &p); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if p.Name != "" || p.Owner == nil || p.Owner.Id != "x" {
+		t.Errorf("failed: decoded %+v", p)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// QueryBuilder assembles a dynamodb.QueryInput over the partition of a
+// struct, optionally narrowed by a condition on a range key.
+type QueryBuilder struct {
+	v     interface{}
+	field string
+	op    string
+	value interface{}
+	rcc   string
+}
+
+// Query begins a QueryInput over the partition holding v, a struct (or
+// pointer to struct) whose HASH field is set.
+func Query(v interface{}) *QueryBuilder {
+	return &QueryBuilder{v: v}
+}
+
+// UsingRange selects the Go field acting as the range key of the query.
+// A table may sort its items by several fields: its own RANGE, and the
+// RANGE of each secondary index (`dynaGo:",RANGE=ByCreatedAt"`).
+// Choosing the range of an index queries that index, over the partition
+// of the index's own HASH field for a global index, or of the table's
+// HASH for a local one. Without UsingRange the table's RANGE is used.
+func (b *QueryBuilder) UsingRange(field string) *QueryBuilder {
+	b.field = field
+	return b
+}
+
+// Range narrows the query to the items whose range key compares to value
+// by op, one of =, <, <=, > or >=.
+func (b *QueryBuilder) Range(op string, value interface{}) *QueryBuilder {
+	b.op, b.value = op, value
+	return b
+}
+
+// ReturnConsumedCapacity asks dynamoDB to report the capacity the query
+// consumes, TOTAL or by INDEXES, or NONE (the default).
+func (b *QueryBuilder) ReturnConsumedCapacity(rcc string) *QueryBuilder {
+	b.rcc = rcc
+	return b
+}
+
+// Input builds the QueryInput. The key condition aliases every name and
+// value, as filter expressions do.
+func (b *QueryBuilder) Input() (in *dynamodb.QueryInput, err error) {
+	defer recoverError(&err)
+	v := reflect.Indirect(reflect.ValueOf(b.v))
+	if v.Kind() != reflect.Struct {
+		return nil, &OnlyStructsSupportedError{v.Kind()}
+	}
+	t := v.Type()
+	tn := TableName(t)
+	in = &dynamodb.QueryInput{TableName: &tn}
+
+	x := newExpression(t, nil, nil, nil)
+	field, index, err := b.rangeField(t)
+	if err != nil {
+		return nil, err
+	}
+	pki := getPartitionKey(t)
+	if index != "" {
+		in.IndexName = &index
+		if hki, ok := indexHashKey(t, index); ok {
+			pki = hki
+		}
+	}
+	_, pv, err := keyAttribute(v, pki, nil)
+	if err != nil {
+		return nil, err
+	}
+	n, err := x.name(t.Field(pki[0]).Name)
+	if err != nil {
+		return nil, err
+	}
+	kce := n + " = " + x.attributeValue(&pv)
+
+	if b.op != "" {
+		switch b.op {
+		case "=", "<", "<=", ">", ">=":
+		default:
+			return nil, &UnsupportedOperatorError{b.op}
+		}
+		if field == "" {
+			return nil, &MissingKeyError{Type: t, KeyType: dynamodb.KeyTypeRange}
+		}
+		n, err := x.name(field)
+		if err != nil {
+			return nil, err
+		}
+		var val string
+		sf, _ := t.FieldByName(field)
+		if pad, ok := stringInt(sf); ok && isInt(reflect.ValueOf(b.value)) {
+			// compared as the field stores it
+			s := formatStringInt(reflect.ValueOf(b.value).Int(), pad)
+			val = x.attributeValue(&dynamodb.AttributeValue{S: &s})
+		} else if val, err = x.value(b.value); err != nil {
+			return nil, err
+		}
+		kce += " AND " + n + " " + b.op + " " + val
+	}
+	in.KeyConditionExpression = &kce
+	if err := checkConsumedCapacity(b.rcc); err != nil {
+		return nil, err
+	}
+	if b.rcc != "" {
+		in.ReturnConsumedCapacity = &b.rcc
+	}
+	in.ExpressionAttributeNames = x.names
+	in.ExpressionAttributeValues = x.values
+	return in, nil
+}
+
+// the Go name of the range field of the query, and the index it is the
+// range of ("" for the table). The field is "" when the table has no
+// RANGE and none was chosen.
+func (b *QueryBuilder) rangeField(t reflect.Type) (string, string, error) {
+	if b.field == "" {
+		rki, err := getRangeKey(t)
+		if err != nil {
+			return "", "", nil
+		}
+		return t.Field(rki[0]).Name, "", nil
+	}
+	sf, ok := t.FieldByName(b.field)
+	if !ok {
+		return "", "", &UnknownFieldError{t, b.field}
+	}
+	switch sf.Type.Kind() {
+	case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+	default:
+		return "", "", &TableKeyCannotBeTypeError{sf.Type}
+	}
+	_, o := parseTag(sf.Tag.Get("dynaGo"))
+	if o.Contains(dynamodb.KeyTypeRange) {
+		return sf.Name, "", nil
+	}
+	if in := o.Values(dynamodb.KeyTypeRange); len(in) > 0 {
+		return sf.Name, in[0], nil
+	}
+	return "", "", &NotRangeKeyError{t, b.field}
+}
+
+// the index path of the field tagged HASH=index, the partition key of
+// the global secondary index, false when index is local and so shares
+// the table's HASH
+func indexHashKey(t reflect.Type, index string) ([]int, bool) {
+	for n := 0; n < t.NumField(); n++ {
+		_, o := parseTag(t.Field(n).Tag.Get("dynaGo"))
+		for _, in := range o.Values(dynamodb.KeyTypeHash) {
+			if in == index {
+				return []int{n}, true
+			}
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,80 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"testing"
+)
+
+type Order struct {
+	Customer  string `dynaGo:",HASH"`
+	OrderId   string `dynaGo:",RANGE"`
+	CreatedAt int64  `dynaGo:"created,RANGE=ByCreated"`
+	Total     int
+	Items     []string
+}
+
+func TestQueryUsingRange(t *testing.T) {
+	o := Order{Customer: "c1"}
+
+	in, err := Query(o).Range(">=", "o-100").Input()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if in.IndexName != nil {
+		t.Errorf("failed: table range queried index %s", *in.IndexName)
+	}
+	if *in.KeyConditionExpression != "#n0 = :v0 AND #n1 >= :v1" ||
+		*in.ExpressionAttributeNames["#n1"] != "OrderId" ||
+		*in.ExpressionAttributeValues[":v1"].S != "o-100" {
+		t.Errorf("failed: key condition %s %v %v", *in.KeyConditionExpression,
+			in.ExpressionAttributeNames, in.ExpressionAttributeValues)
+	}
+
+	in, err = Query(&o).UsingRange("CreatedAt").Range("<", 1500).Input()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if in.IndexName == nil || *in.IndexName != "ByCreated" {
+		t.Errorf("failed: index %v", in.IndexName)
+	}
+	if *in.ExpressionAttributeNames["#n0"] != "Customer" || *in.ExpressionAttributeValues[":v0"].S != "c1" ||
+		*in.ExpressionAttributeNames["#n1"] != "created" || *in.ExpressionAttributeValues[":v1"].N != "1500" {
+		t.Errorf("failed: key condition %s %v %v", *in.KeyConditionExpression,
+			in.ExpressionAttributeNames, in.ExpressionAttributeValues)
+	}
+
+	if _, err := Query(o).UsingRange("Nope").Input(); err == nil {
+		t.Error("failed: expected error for unknown field")
+	}
+	if _, err := Query(o).UsingRange("Total").Input(); err == nil {
+		t.Error("failed: expected error for a field which isn't a range key")
+	}
+	if _, err := Query(o).UsingRange("Items").Input(); err == nil {
+		t.Error("failed: expected error for a non-scalar field")
+	}
+}
+
+func TestQueryGlobalIndexRange(t *testing.T) {
+	type Shipment struct {
+		Id        string `dynaGo:",HASH"`
+		Carrier   string `dynaGo:",HASH=ByCarrier"`
+		ShippedAt int64  `dynaGo:",RANGE=ByCarrier"`
+	}
+	s := Shipment{Id: "s1", Carrier: "ups"}
+	in, err := Query(s).UsingRange("ShippedAt").Range(">", 100).Input()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if in.IndexName == nil || *in.IndexName != "ByCarrier" {
+		t.Errorf("failed: index %v", in.IndexName)
+	}
+	if *in.KeyConditionExpression != "#n0 = :v0 AND #n1 > :v1" ||
+		*in.ExpressionAttributeNames["#n0"] != "Carrier" || *in.ExpressionAttributeValues[":v0"].S != "ups" ||
+		*in.ExpressionAttributeNames["#n1"] != "ShippedAt" {
+		t.Errorf("failed: key condition %s %v %v", *in.KeyConditionExpression,
+			in.ExpressionAttributeNames, in.ExpressionAttributeValues)
+	}
+}
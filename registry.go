@@ -0,0 +1,104 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// the types of RegisterType by name
+var registry = struct {
+	sync.RWMutex
+	types map[string]reflect.Type
+}{types: make(map[string]reflect.Type)}
+
+// RegisterType makes the struct type of v (a struct or pointer to
+// struct) known by name, for UnmarshalPolymorphic to decode items naming
+// it. Registering a name again replaces its type. Registration is
+// usually done in init.
+func RegisterType(name string, v interface{}) {
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		panic(&OnlyStructsSupportedError{reflect.ValueOf(v).Kind()})
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	registry.types[name] = t
+}
+
+// the migrations of RegisterMigration by type
+var migrations = struct {
+	sync.RWMutex
+	fns map[reflect.Type][]func(map[string]*dynamodb.AttributeValue)
+}{fns: make(map[reflect.Type][]func(map[string]*dynamodb.AttributeValue))}
+
+// RegisterMigration has Unmarshal pass each item decoded into the struct
+// type of v (a struct or pointer to struct) through fn before its
+// attributes are mapped to fields, so items written under an older
+// schema can be brought up to date as they are read (renaming an
+// attribute, say) instead of by rewriting the table. fn is given a copy
+// of the item, free to change, and the item passed to Unmarshal is left
+// as it was. Migrations registered for a type run in the order they were
+// registered. Registration is usually done in init.
+func RegisterMigration(v interface{}, fn func(map[string]*dynamodb.AttributeValue)) {
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		panic(&OnlyStructsSupportedError{reflect.ValueOf(v).Kind()})
+	}
+	migrations.Lock()
+	defer migrations.Unlock()
+	migrations.fns[t] = append(migrations.fns[t], fn)
+}
+
+// m after the migrations registered for t, m itself if there are none
+func migrate(m map[string]*dynamodb.AttributeValue, t reflect.Type) map[string]*dynamodb.AttributeValue {
+	migrations.RLock()
+	fns := migrations.fns[t]
+	migrations.RUnlock()
+	if len(fns) == 0 {
+		return m
+	}
+	mm := make(map[string]*dynamodb.AttributeValue, len(m))
+	for an, av := range m {
+		mm[an] = av
+	}
+	for _, fn := range fns {
+		fn(mm)
+	}
+	return mm
+}
+
+// UnmarshalPolymorphic decodes item into a new value of the type
+// registered under the name held by its discriminator attribute, and
+// returns a pointer to it. This suits tables holding several types of
+// item (single table designs), told apart by an attribute such as
+// "Type". The discriminator must be an S attribute naming a registered
+// type.
+func UnmarshalPolymorphic(item map[string]*dynamodb.AttributeValue, discriminator string) (interface{}, error) {
+	av, ok := item[discriminator]
+	if !ok || av.S == nil {
+		return nil, &DiscriminatorError{discriminator}
+	}
+	registry.RLock()
+	t, ok := registry.types[*av.S]
+	registry.RUnlock()
+	if !ok {
+		return nil, &UnregisteredTypeError{*av.S}
+	}
+	pv := reflect.New(t)
+	if err := Unmarshal(item, pv.Interface()); err != nil {
+		return nil, err
+	}
+	return pv.Interface(), nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+type Customer struct {
+	PK   string `dynaGo:",HASH"`
+	Type string
+	Name string
+}
+
+type Shipment struct {
+	PK      string `dynaGo:",HASH"`
+	Type    string
+	Carrier string
+}
+
+func TestUnmarshalPolymorphic(t *testing.T) {
+	RegisterType("customer", Customer{})
+	RegisterType("shipment", &Shipment{})
+
+	items := []map[string]*dynamodb.AttributeValue{
+		Marshal(Customer{"c#1", "customer", "Ada"}).Item,
+		Marshal(Shipment{"s#1", "shipment", "DHL"}).Item,
+	}
+	v, err := UnmarshalPolymorphic(items[0], "Type")
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if c, ok := v.(*Customer); !ok || c.Name != "Ada" {
+		t.Errorf("failed: decoded %#v", v)
+	}
+	v, err = UnmarshalPolymorphic(items[1], "Type")
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if s, ok := v.(*Shipment); !ok || s.Carrier != "DHL" {
+		t.Errorf("failed: decoded %#v", v)
+	}
+
+	unknown := Marshal(Customer{"x#1", "invoice", ""}).Item
+	if _, err := UnmarshalPolymorphic(unknown, "Type"); err == nil {
+		t.Error("failed: expected UnregisteredTypeError")
+	}
+	if _, err := UnmarshalPolymorphic(items[0], "Kind"); err == nil {
+		t.Error("failed: expected DiscriminatorError")
+	}
+}
+
+func TestRegisterMigration(t *testing.T) {
+	type Profile struct {
+		Id       string `dynaGo:",HASH"`
+		FullName string
+	}
+	RegisterMigration(&Profile{}, func(item map[string]*dynamodb.AttributeValue) {
+		if av, ok := item["Name"]; ok {
+			item["FullName"] = av
+			delete(item, "Name")
+		}
+	})
+	name := "Ada Lovelace"
+	old := map[string]*dynamodb.AttributeValue{
+		"Id":   {S: &name},
+		"Name": {S: &name},
+	}
+	var p Profile
+	if err := Unmarshal(old, &p); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if p.FullName != name {
+		t.Errorf("failed: migrated to %+v", p)
+	}
+	if _, ok := old["Name"]; !ok {
+		t.Error("failed: the item passed to Unmarshal was changed")
+	}
+}
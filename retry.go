@@ -0,0 +1,102 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// RetryPolicy paces the retries of the runners which resubmit what
+// dynamoDB leaves unprocessed (BatchWriteAll, BatchGetAll) or throttles
+// (the pages of ParallelScanAll). A request is sent at most MaxAttempts
+// times, the first included. The wait before the first retry is
+// BaseDelay, doubled for each retry after it up to MaxDelay (20s if
+// zero), and shortened by up to Jitter (a fraction of 0 to 1) of itself
+// at random so throttled clients don't retry in step.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Jitter      float64
+}
+
+// the longest wait between retries of a policy with no MaxDelay
+const maxRetryDelay = 20 * time.Second
+
+// DefaultRetryPolicy is the policy of an Encoder given none: MaxBatchAttempts
+// attempts, from a 50ms delay with a quarter of it jittered.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: MaxBatchAttempts, BaseDelay: batchBackoff, Jitter: 0.25}
+}
+
+// SetRetryPolicy sets the retry policy of the runners, see
+// Encoder.SetRetryPolicy.
+func SetRetryPolicy(p RetryPolicy) {
+	std.SetRetryPolicy(p)
+}
+
+// the attempts allowed, at least one
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// the wait before retry n, counting from 1; the doubling stops at the
+// cap rather than shifting past it, which would overflow
+func (p RetryPolicy) delay(n int) time.Duration {
+	max := p.MaxDelay
+	if max <= 0 {
+		max = maxRetryDelay
+	}
+	d := p.BaseDelay
+	for i := 1; i < n && d > 0 && d < max; i++ {
+		d <<= 1
+	}
+	if d > max {
+		d = max
+	}
+	if p.Jitter > 0 && d > 0 {
+		d -= time.Duration(rand.Float64() * p.Jitter * float64(d))
+	}
+	return d
+}
+
+// waits out the delay before retry n, returning ctx.Err() if ctx is
+// cancelled first
+func (p RetryPolicy) wait(ctx context.Context, n int) error {
+	d := p.delay(n)
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	select {
+	case <-ctx.Done():
+		t.Stop()
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// reports whether err is dynamoDB throttling the request
+func isThrottled(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	if !ok {
+		return false
+	}
+	switch aerr.Code() {
+	case dynamodb.ErrCodeProvisionedThroughputExceededException,
+		dynamodb.ErrCodeRequestLimitExceeded, "ThrottlingException":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,252 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// ScanAll reads every item of a table into out, which must point to a
+// slice of structs (or of pointers to structs). The table is named after
+// the slice's element type, and pages are followed by LastEvaluatedKey
+// until the scan is complete. Decoded items are appended to the slice.
+func ScanAll(svc dynamodbiface.DynamoDBAPI, out interface{}) error {
+	return scanAll(svc, out, nil)
+}
+
+// ScanAllCapacity is ScanAll which also returns the capacity consumed by
+// the pages of the scan.
+func ScanAllCapacity(svc dynamodbiface.DynamoDBAPI, out interface{}) (Capacity, error) {
+	c := make(Capacity)
+	return c, scanAll(svc, out, c)
+}
+
+// scans into out, adding up the consumed capacity in c unless it's nil
+func scanAll(svc dynamodbiface.DynamoDBAPI, out interface{}, c Capacity) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return &InvalidDecodeError{reflect.TypeOf(out)}
+	}
+	sv := rv.Elem()
+	et := sv.Type().Elem()
+	tn, err := std.tableName(et)
+	if err != nil {
+		return err
+	}
+	in := &dynamodb.ScanInput{TableName: &tn}
+	if c != nil {
+		rcc := dynamodb.ReturnConsumedCapacityTotal
+		in.ReturnConsumedCapacity = &rcc
+	}
+	for {
+		resp, err := svc.Scan(in)
+		if err != nil {
+			return err
+		}
+		if c != nil {
+			c.add(resp.ConsumedCapacity)
+		}
+		for _, item := range resp.Items {
+			ev, err := newItem(item, et)
+			if err != nil {
+				return err
+			}
+			sv = reflect.Append(sv, ev)
+		}
+		rv.Elem().Set(sv)
+		if len(resp.LastEvaluatedKey) == 0 {
+			return nil
+		}
+		in.ExclusiveStartKey = resp.LastEvaluatedKey
+	}
+}
+
+// ScanBuilder assembles a dynamodb.ScanInput for paging through a table
+// by hand.
+type ScanBuilder struct {
+	enc   *Encoder
+	v     interface{}
+	limit int64
+	start map[string]*dynamodb.AttributeValue
+	conds []Condition
+	rcc   string
+}
+
+// Scan begins a ScanInput over the table holding v. Page by passing the
+// LastEvaluatedKey of each ScanOutput to StartKey of the next Scan, until
+// it is empty.
+func Scan(v interface{}) *ScanBuilder {
+	return std.Scan(v)
+}
+
+// Limit reads at most n items per page (before any filter is applied)
+func (b *ScanBuilder) Limit(n int64) *ScanBuilder {
+	b.limit = n
+	return b
+}
+
+// StartKey continues the scan after the item with key k, the
+// LastEvaluatedKey of the previous page. A nil k starts from the
+// beginning.
+func (b *ScanBuilder) StartKey(k map[string]*dynamodb.AttributeValue) *ScanBuilder {
+	b.start = k
+	return b
+}
+
+// Filter keeps only the items matching the AND of conds, see Filter.
+func (b *ScanBuilder) Filter(conds ...Condition) *ScanBuilder {
+	b.conds = append(b.conds, conds...)
+	return b
+}
+
+// ReturnConsumedCapacity asks dynamoDB to report the capacity the scan
+// consumes, TOTAL or by INDEXES, or NONE (the default).
+func (b *ScanBuilder) ReturnConsumedCapacity(rcc string) *ScanBuilder {
+	b.rcc = rcc
+	return b
+}
+
+// Input builds the ScanInput
+func (b *ScanBuilder) Input() (*dynamodb.ScanInput, error) {
+	if err := checkConsumedCapacity(b.rcc); err != nil {
+		return nil, err
+	}
+	tn, err := b.enc.tableName(reflect.TypeOf(b.v))
+	if err != nil {
+		return nil, err
+	}
+	si := &dynamodb.ScanInput{TableName: &tn}
+	if b.limit > 0 {
+		si.Limit = &b.limit
+	}
+	if len(b.start) > 0 {
+		si.ExclusiveStartKey = b.start
+	}
+	if b.rcc != "" {
+		si.ReturnConsumedCapacity = &b.rcc
+	}
+	if err := applyFilter(si, b.v, b.enc.namer, b.conds); err != nil {
+		return nil, err
+	}
+	return si, nil
+}
+
+// ScanSegment returns a ScanInput over segment (counting from 0) of the
+// total segments of the table holding v, filtered by the AND of conds.
+// Each segment can be scanned independently, see ParallelScanAll.
+func ScanSegment(v interface{}, segment, total int64, conds ...Condition) (*dynamodb.ScanInput, error) {
+	si, err := ScanInput(v, conds...)
+	if err != nil {
+		return nil, err
+	}
+	si.Segment, si.TotalSegments = &segment, &total
+	return si, nil
+}
+
+// ParallelScanAll is ScanAll with the table divided into segments, each
+// scanned by its own goroutine. A throttled page is retried as the
+// RetryPolicy allows (see SetRetryPolicy). Items are appended to out as segments
+// complete, so their order follows no segment. The first failure, or
+// the cancellation of ctx, stops every segment. The failures of
+// segments are returned together as a ScanSegmentsError, or ctx.Err()
+// when ctx was cancelled. segments less than 1 is a SegmentCountError.
+func ParallelScanAll(ctx context.Context, svc dynamodbiface.DynamoDBAPI, out interface{}, segments int) error {
+	if segments < 1 {
+		return &SegmentCountError{segments}
+	}
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return &InvalidDecodeError{reflect.TypeOf(out)}
+	}
+	et := rv.Elem().Type().Elem()
+	tn, err := std.tableName(et)
+	if err != nil {
+		return err
+	}
+	sctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs = make(ScanSegmentsError)
+	)
+	total := int64(segments)
+	for n := int64(0); n < total; n++ {
+		wg.Add(1)
+		go func(segment int64) {
+			defer wg.Done()
+			in := &dynamodb.ScanInput{TableName: &tn, Segment: &segment, TotalSegments: &total}
+			items, err := scanSegment(sctx, svc, in, et)
+			mu.Lock()
+			defer mu.Unlock()
+			// a segment stopped by the failure of another isn't reported
+			if err != nil && sctx.Err() == nil {
+				errs[segment] = err
+				cancel()
+			}
+			if err == nil {
+				rv.Elem().Set(reflect.Append(rv.Elem(), items...))
+			}
+		}(n)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// reads every page of the segment in, decoding the items to type et. A
+// throttled page is retried as the RetryPolicy allows.
+func scanSegment(ctx context.Context, svc dynamodbiface.DynamoDBAPI, in *dynamodb.ScanInput, et reflect.Type) ([]reflect.Value, error) {
+	var items []reflect.Value
+	p := std.retryPolicy()
+	for attempt := 1; ; {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		resp, err := svc.ScanWithContext(ctx, in)
+		if isThrottled(err) && attempt < p.attempts() {
+			if err := p.wait(ctx, attempt); err != nil {
+				return nil, err
+			}
+			attempt++
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		attempt = 1
+		for _, item := range resp.Items {
+			ev, err := newItem(item, et)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, ev)
+		}
+		if len(resp.LastEvaluatedKey) == 0 {
+			return items, nil
+		}
+		in.ExclusiveStartKey = resp.LastEvaluatedKey
+	}
+}
+
+// decodes item into a new value of type t, a struct or pointer to struct
+func newItem(item map[string]*dynamodb.AttributeValue, t reflect.Type) (reflect.Value, error) {
+	if t.Kind() == reflect.Ptr {
+		pv := reflect.New(t.Elem())
+		return pv, Unmarshal(item, pv.Interface())
+	}
+	pv := reflect.New(t)
+	return pv.Elem(), Unmarshal(item, pv.Interface())
+}
@@ -0,0 +1,104 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestParallelScanAll(t *testing.T) {
+	page := func(last bool, ids ...string) *dynamodb.ScanOutput {
+		out := &dynamodb.ScanOutput{}
+		for _, id := range ids {
+			out.Items = append(out.Items, Marshal(Usr{Id: id}).Item)
+		}
+		if !last {
+			out.LastEvaluatedKey = Marshal(Usr{Id: ids[len(ids)-1]}).Item
+		}
+		return out
+	}
+	svc := &stubDynamo{segmentOut: map[int64][]*dynamodb.ScanOutput{
+		0: {page(false, "1", "2"), page(true, "3")},
+		1: {page(true, "4")},
+	}}
+	var us []Usr
+	if err := ParallelScanAll(context.Background(), svc, &us, 2); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	ids := make([]string, len(us))
+	for n, u := range us {
+		ids[n] = u.Id
+	}
+	sort.Strings(ids)
+	if len(ids) != 4 || ids[0] != "1" || ids[3] != "4" {
+		t.Errorf("failed: scanned %v", ids)
+	}
+	if len(svc.scanIn) != 3 {
+		t.Errorf("failed: %d scans", len(svc.scanIn))
+	}
+	for _, in := range svc.scanIn {
+		if *in.TotalSegments != 2 {
+			t.Errorf("failed: total segments %d", *in.TotalSegments)
+		}
+	}
+
+	for _, segments := range []int{0, -1} {
+		err := ParallelScanAll(context.Background(), &stubDynamo{}, &us, segments)
+		if _, ok := err.(*SegmentCountError); !ok {
+			t.Errorf("failed: expected SegmentCountError for %d segments, got %v", segments, err)
+		}
+	}
+
+	fail := errors.New("throttled")
+	svc = &stubDynamo{
+		segmentOut: map[int64][]*dynamodb.ScanOutput{0: {page(true, "1")}},
+		segmentErr: map[int64]error{1: fail},
+	}
+	err := ParallelScanAll(context.Background(), svc, &us, 2)
+	if se, ok := err.(ScanSegmentsError); !ok || se[1] != fail {
+		t.Errorf("failed: expected segment 1 failure, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := ParallelScanAll(ctx, &stubDynamo{}, &us, 2); err != context.Canceled {
+		t.Errorf("failed: expected cancellation, got %v", err)
+	}
+}
+
+func TestScanBuilder(t *testing.T) {
+	id := "1000"
+	last := map[string]*dynamodb.AttributeValue{"UserId": {S: &id}}
+	si, err := Scan(Usr{}).Limit(10).StartKey(last).Input()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if *si.TableName != TableName(reflect.TypeOf(Usr{})) {
+		t.Errorf("failed: table name %s", *si.TableName)
+	}
+	if si.Limit == nil || *si.Limit != 10 {
+		t.Errorf("failed: limit %v", si.Limit)
+	}
+	if !reflect.DeepEqual(si.ExclusiveStartKey, last) {
+		t.Errorf("failed: start key %v", si.ExclusiveStartKey)
+	}
+	if si.FilterExpression != nil {
+		t.Errorf("failed: unexpected filter %s", *si.FilterExpression)
+	}
+
+	si, err = Scan(&Usr{}).Filter(Filter("Email", "=", "a@b.c")).Input()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if si.Limit != nil || si.ExclusiveStartKey != nil || si.FilterExpression == nil {
+		t.Errorf("failed: first filtered page %v", si)
+	}
+}
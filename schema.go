@@ -0,0 +1,201 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// KeyAttribute is an attribute of a key schema: its name, KeyType (HASH
+// or RANGE) and scalar AttributeType (S, N or B).
+type KeyAttribute struct {
+	AttributeName string
+	KeyType       string
+	AttributeType string
+}
+
+// KeyChange is a key attribute declared (Want) and described (Got) under
+// the same name, but with another key or attribute type.
+type KeyChange struct {
+	Want, Got KeyAttribute
+}
+
+// Diff is the difference between the schema a struct declares and the
+// schema of its table as described by dynamoDB. Added holds what the
+// struct declares but the table lacks, Removed what the table has but
+// the struct no longer declares. Indexes are named, and an index whose
+// key schema differs is Changed.
+type Diff struct {
+	AddedKeys   []KeyAttribute
+	RemovedKeys []KeyAttribute
+	ChangedKeys []KeyChange
+
+	AddedIndexes   []string
+	RemovedIndexes []string
+	ChangedIndexes []string
+}
+
+// Empty reports whether the struct and table agree, no change needed.
+func (d Diff) Empty() bool {
+	return len(d.AddedKeys) == 0 && len(d.RemovedKeys) == 0 && len(d.ChangedKeys) == 0 &&
+		len(d.AddedIndexes) == 0 && len(d.RemovedIndexes) == 0 && len(d.ChangedIndexes) == 0
+}
+
+// SchemaDiff compares the key schema and secondary indexes v declares,
+// as BuildCreateTableInput builds them, with those DescribeTable reports
+// for v's table. A changed table key can't be updated in place, but
+// added and removed global indexes can be, by UpdateTable. Attributes
+// and indexes are listed in name order.
+func SchemaDiff(svc dynamodbiface.DynamoDBAPI, v interface{}) (d Diff, err error) {
+	want, err := BuildCreateTableInput(v, 1, 1)
+	if err != nil {
+		return d, err
+	}
+	resp, err := svc.DescribeTable(&dynamodb.DescribeTableInput{TableName: want.TableName})
+	if err != nil {
+		return d, err
+	}
+	got := resp.Table
+	wk := keyAttributes(want.KeySchema, want.AttributeDefinitions)
+	gk := keyAttributes(got.KeySchema, got.AttributeDefinitions)
+	names := make(map[string]bool)
+	for an := range wk {
+		names[an] = true
+	}
+	for an := range gk {
+		names[an] = true
+	}
+	for _, an := range sortedNames(names) {
+		w, inWant := wk[an]
+		g, inGot := gk[an]
+		switch {
+		case !inGot:
+			d.AddedKeys = append(d.AddedKeys, w)
+		case !inWant:
+			d.RemovedKeys = append(d.RemovedKeys, g)
+		case w != g:
+			d.ChangedKeys = append(d.ChangedKeys, KeyChange{w, g})
+		}
+	}
+	names = make(map[string]bool)
+	wi := make(map[string]string)
+	for _, g := range want.GlobalSecondaryIndexes {
+		wi[*g.IndexName], names[*g.IndexName] = keySchemaString(g.KeySchema), true
+	}
+	for _, l := range want.LocalSecondaryIndexes {
+		wi[*l.IndexName], names[*l.IndexName] = keySchemaString(l.KeySchema), true
+	}
+	gi := make(map[string]string)
+	for _, g := range got.GlobalSecondaryIndexes {
+		gi[*g.IndexName], names[*g.IndexName] = keySchemaString(g.KeySchema), true
+	}
+	for _, l := range got.LocalSecondaryIndexes {
+		gi[*l.IndexName], names[*l.IndexName] = keySchemaString(l.KeySchema), true
+	}
+	for _, name := range sortedNames(names) {
+		w, inWant := wi[name]
+		g, inGot := gi[name]
+		switch {
+		case !inGot:
+			d.AddedIndexes = append(d.AddedIndexes, name)
+		case !inWant:
+			d.RemovedIndexes = append(d.RemovedIndexes, name)
+		case w != g:
+			d.ChangedIndexes = append(d.ChangedIndexes, name)
+		}
+	}
+	return d, nil
+}
+
+// AddGSI creates the global secondary index indexName, as tagged on v's
+// fields, on v's existing table with UpdateTable. The index has the key
+// schema and projection BuildCreateTableInput gives it, and the
+// throughput of its rcu and wcu options or else of the table as
+// DescribeTable reports it (none for an on demand table). An index v
+// doesn't tag is an UnknownIndexError, and one the table already has an
+// IndexExistsError. dynamoDB builds the index in the background, it is
+// not ACTIVE when AddGSI returns.
+func AddGSI(svc dynamodbiface.DynamoDBAPI, v interface{}, indexName string) error {
+	tn, err := std.tableName(reflect.TypeOf(v))
+	if err != nil {
+		return err
+	}
+	resp, err := svc.DescribeTable(&dynamodb.DescribeTableInput{TableName: &tn})
+	if err != nil {
+		return err
+	}
+	for _, g := range resp.Table.GlobalSecondaryIndexes {
+		if *g.IndexName == indexName {
+			return &IndexExistsError{tn, indexName}
+		}
+	}
+	var w, r int64
+	if pt := resp.Table.ProvisionedThroughput; pt != nil && pt.WriteCapacityUnits != nil && pt.ReadCapacityUnits != nil {
+		w, r = *pt.WriteCapacityUnits, *pt.ReadCapacityUnits
+	}
+	ct, err := BuildCreateTableInput(v, w, r)
+	if err != nil {
+		return err
+	}
+	var gsi *dynamodb.GlobalSecondaryIndex
+	for _, g := range ct.GlobalSecondaryIndexes {
+		if *g.IndexName == indexName {
+			gsi = g
+		}
+	}
+	if gsi == nil {
+		return &UnknownIndexError{reflect.Indirect(reflect.ValueOf(v)).Type(), indexName}
+	}
+	keys := make(map[string]bool, len(gsi.KeySchema))
+	for _, k := range gsi.KeySchema {
+		keys[*k.AttributeName] = true
+	}
+	defs := make([]*dynamodb.AttributeDefinition, 0, len(keys))
+	for _, ad := range ct.AttributeDefinitions {
+		if keys[*ad.AttributeName] {
+			defs = append(defs, ad)
+		}
+	}
+	_, err = svc.UpdateTable(&dynamodb.UpdateTableInput{
+		TableName:            &tn,
+		AttributeDefinitions: defs,
+		GlobalSecondaryIndexUpdates: []*dynamodb.GlobalSecondaryIndexUpdate{{
+			Create: &dynamodb.CreateGlobalSecondaryIndexAction{
+				IndexName:             gsi.IndexName,
+				KeySchema:             gsi.KeySchema,
+				Projection:            gsi.Projection,
+				ProvisionedThroughput: gsi.ProvisionedThroughput,
+			},
+		}},
+	})
+	return err
+}
+
+// the attributes of the key schema ks by name, typed by defs
+func keyAttributes(ks []*dynamodb.KeySchemaElement, defs []*dynamodb.AttributeDefinition) map[string]KeyAttribute {
+	types := make(map[string]string, len(defs))
+	for _, ad := range defs {
+		types[*ad.AttributeName] = *ad.AttributeType
+	}
+	kas := make(map[string]KeyAttribute, len(ks))
+	for _, k := range ks {
+		kas[*k.AttributeName] = KeyAttribute{*k.AttributeName, *k.KeyType, types[*k.AttributeName]}
+	}
+	return kas
+}
+
+// the names of the set, sorted
+func sortedNames(names map[string]bool) []string {
+	ns := make([]string, 0, len(names))
+	for n := range names {
+		ns = append(ns, n)
+	}
+	sort.Strings(ns)
+	return ns
+}
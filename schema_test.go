@@ -0,0 +1,118 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestSchemaDiff(t *testing.T) {
+	type Order struct {
+		Customer string `dynaGo:",HASH"`
+		Placed   int    `dynaGo:",RANGE"`
+		Status   string `dynaGo:",HASH=ByStatus"`
+		Region   string `dynaGo:",RANGE=ByRegion"`
+	}
+	ct, err := BuildCreateTableInput(Order{}, 1, 1)
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	svc := &stubDynamo{tables: map[string]*dynamodb.TableDescription{
+		*ct.TableName: {TableName: ct.TableName, KeySchema: ct.KeySchema, AttributeDefinitions: ct.AttributeDefinitions,
+			GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndexDescription{
+				{IndexName: ct.GlobalSecondaryIndexes[0].IndexName, KeySchema: ct.GlobalSecondaryIndexes[0].KeySchema},
+			},
+			LocalSecondaryIndexes: []*dynamodb.LocalSecondaryIndexDescription{
+				{IndexName: ct.LocalSecondaryIndexes[0].IndexName, KeySchema: ct.LocalSecondaryIndexes[0].KeySchema},
+			},
+		},
+	}}
+	if d, err := SchemaDiff(svc, Order{}); err != nil || !d.Empty() {
+		t.Fatalf("failed: matching table diff %+v %v", d, err)
+	}
+
+	s, n := "S", "N"
+	hash, rng := dynamodb.KeyTypeHash, dynamodb.KeyTypeRange
+	id, placed, status := "Id", "Placed", "Status"
+	svc.tables[*ct.TableName] = &dynamodb.TableDescription{
+		TableName: ct.TableName,
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: &id, KeyType: &hash},
+			{AttributeName: &placed, KeyType: &rng},
+		},
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: &id, AttributeType: &s},
+			{AttributeName: &placed, AttributeType: &s},
+		},
+		GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndexDescription{
+			{IndexName: ct.GlobalSecondaryIndexes[0].IndexName, KeySchema: []*dynamodb.KeySchemaElement{
+				{AttributeName: &status, KeyType: &hash}, {AttributeName: &placed, KeyType: &rng},
+			}},
+			{IndexName: &id, KeySchema: []*dynamodb.KeySchemaElement{{AttributeName: &id, KeyType: &hash}}},
+		},
+	}
+	d, err := SchemaDiff(svc, Order{})
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	want := Diff{
+		AddedKeys:      []KeyAttribute{{"Customer", hash, s}},
+		RemovedKeys:    []KeyAttribute{{"Id", hash, s}},
+		ChangedKeys:    []KeyChange{{KeyAttribute{"Placed", rng, n}, KeyAttribute{"Placed", rng, s}}},
+		AddedIndexes:   []string{"ByRegion"},
+		RemovedIndexes: []string{"Id"},
+		ChangedIndexes: []string{"ByStatus"},
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("failed: diff %+v, want %+v", d, want)
+	}
+}
+
+func TestAddGSI(t *testing.T) {
+	type Ticket struct {
+		Id       string `dynaGo:",HASH"`
+		Assignee string `dynaGo:",HASH=ByAssignee,rcu=ByAssignee:4"`
+		Opened   int    `dynaGo:",RANGE=ByAssignee"`
+	}
+	ct, _ := BuildCreateTableInput(Ticket{}, 1, 1)
+	r, w := int64(2), int64(3)
+	svc := &stubDynamo{tables: map[string]*dynamodb.TableDescription{
+		*ct.TableName: {TableName: ct.TableName, KeySchema: ct.KeySchema,
+			ProvisionedThroughput: &dynamodb.ProvisionedThroughputDescription{ReadCapacityUnits: &r, WriteCapacityUnits: &w},
+		},
+	}}
+	if err := AddGSI(svc, Ticket{}, "ByAssignee"); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if len(svc.updated) != 1 {
+		t.Fatalf("failed: %d updates", len(svc.updated))
+	}
+	in := svc.updated[0]
+	c := in.GlobalSecondaryIndexUpdates[0].Create
+	if *in.TableName != *ct.TableName || *c.IndexName != "ByAssignee" ||
+		keySchemaString(c.KeySchema) != "Assignee:HASH Opened:RANGE" || *c.Projection.ProjectionType != "ALL" {
+		t.Errorf("failed: update %v", in)
+	}
+	if *c.ProvisionedThroughput.ReadCapacityUnits != 4 || *c.ProvisionedThroughput.WriteCapacityUnits != 3 {
+		t.Errorf("failed: throughput %v", c.ProvisionedThroughput)
+	}
+	if len(in.AttributeDefinitions) != 2 {
+		t.Errorf("failed: attribute definitions %v", in.AttributeDefinitions)
+	}
+
+	var uie *UnknownIndexError
+	if err := AddGSI(svc, Ticket{}, "ByNothing"); !errors.As(err, &uie) {
+		t.Errorf("failed: expected UnknownIndexError, got %v", err)
+	}
+	svc.tables[*ct.TableName].GlobalSecondaryIndexes = []*dynamodb.GlobalSecondaryIndexDescription{{IndexName: c.IndexName}}
+	var iee *IndexExistsError
+	if err := AddGSI(svc, Ticket{}, "ByAssignee"); !errors.As(err, &iee) || len(svc.updated) != 1 {
+		t.Errorf("failed: expected IndexExistsError, got %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"context"
+	"hash/fnv"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// A HASH key may be write sharded, spreading the items of a hot
+// partition over several,
+//
+//	UserId string `dynaGo:",HASH,shards=8"`
+//	Seq    int64  `dynaGo:",RANGE"`
+//
+// stores UserId "user#123" as one of "user#123#0" to "user#123#7" (the
+// separator set with sep= as for compose), the shard picked by a hash of
+// the RANGE value. The shard follows from the key, so Get, Update,
+// Delete and KeyMap still address a single item, and decoding strips
+// the suffix again.
+//
+// The trade-offs: reading a whole partition takes a query per shard (see
+// QueryShards), whose results arrive shard by shard rather than in RANGE
+// order; the number of shards can't change without rewriting the items;
+// an index on the key attribute sees the suffixed values; and only a
+// string HASH of a table with a RANGE can be sharded. A misused shards
+// option panics with a TagOptionError.
+//
+// keyShards returns the number of shards of the key field sf and the
+// separator of its suffix, if it is sharded.
+func keyShards(sf reflect.StructField) (int, string, bool) {
+	_, o := parseTag(sf.Tag.Get("dynaGo"))
+	ss := o.Values("shards")
+	if len(ss) == 0 {
+		return 0, "", false
+	}
+	n, err := strconv.Atoi(ss[0])
+	if err != nil || n < 1 || !o.Contains(dynamodb.KeyTypeHash) || sf.Type.Kind() != reflect.String {
+		panic(&TagOptionError{sf.Name, "shards=" + ss[0]})
+	}
+	sep := "#"
+	if seps := o.Values("sep"); len(seps) > 0 {
+		sep = seps[0]
+	}
+	return n, sep, true
+}
+
+// key, or the value of the field sf of the struct v when key is the zero
+// Value, suffixed with its shard when sf is sharded. The result is false
+// when there's neither a key given nor a shard to add.
+func shardedKey(v reflect.Value, sf reflect.StructField, key reflect.Value) (reflect.Value, bool) {
+	n, sep, ok := keyShards(sf)
+	if !ok {
+		return key, key.IsValid()
+	}
+	if !key.IsValid() {
+		if key, ok = fieldByIndex(v, sf.Index); !ok {
+			key = reflect.Zero(sf.Type)
+		}
+	}
+	s := key.String() + sep + strconv.Itoa(shardOf(v, sf, n))
+	return reflect.ValueOf(s).Convert(sf.Type), true
+}
+
+// the shard of the item v, a hash of its RANGE value, of n
+func shardOf(v reflect.Value, sf reflect.StructField, n int) int {
+	rki, err := getRangeKey(v.Type())
+	if err != nil {
+		panic(&TagOptionError{sf.Name, "shards=" + strconv.Itoa(n)})
+	}
+	_, rv, err := keyAttribute(v, rki, nil)
+	if err != nil {
+		panic(err)
+	}
+	h := fnv.New32a()
+	switch {
+	case rv.S != nil:
+		h.Write([]byte(*rv.S))
+	case rv.N != nil:
+		h.Write([]byte(*rv.N))
+	default:
+		h.Write(rv.B)
+	}
+	return int(h.Sum32() % uint32(n))
+}
+
+// the stored sharded key s without its shard suffix
+func unshardKey(s, sep string) string {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// ShardInputs builds a QueryInput for each shard of the partition of v,
+// when its HASH is sharded (see keyShards), in shard order. Input alone
+// queries just the shard v itself belongs to. The partition is that of
+// the index queried, when it is global. An unsharded partition has a
+// single input, that of Input.
+func (b *QueryBuilder) ShardInputs() ([]*dynamodb.QueryInput, error) {
+	in, err := b.Input()
+	if err != nil {
+		return nil, err
+	}
+	// the partition queried is that of a global index when one is used,
+	// a local index sharing the table's
+	t := reflect.Indirect(reflect.ValueOf(b.v)).Type()
+	pki := getPartitionKey(t)
+	if in.IndexName != nil {
+		if hki, ok := indexHashKey(t, *in.IndexName); ok {
+			pki = hki
+		}
+	}
+	n, sep, ok := keyShards(t.Field(pki[0]))
+	pv := in.ExpressionAttributeValues[":v0"]
+	if !ok || pv == nil || pv.S == nil {
+		return []*dynamodb.QueryInput{in}, nil
+	}
+	base := unshardKey(*pv.S, sep)
+	ins := make([]*dynamodb.QueryInput, n)
+	for shard := range ins {
+		c := *in
+		c.ExpressionAttributeValues = make(map[string]*dynamodb.AttributeValue, len(in.ExpressionAttributeValues))
+		for k, av := range in.ExpressionAttributeValues {
+			c.ExpressionAttributeValues[k] = av
+		}
+		// the partition value is the first aliased by Input
+		s := base + sep + strconv.Itoa(shard)
+		c.ExpressionAttributeValues[":v0"] = &dynamodb.AttributeValue{S: &s}
+		ins[shard] = &c
+	}
+	return ins, nil
+}
+
+// QueryShards reads the partition of a sharded HASH key whole, querying
+// each of the ShardInputs of b and appending the items decoded from
+// every page of every shard to out, a pointer to a slice of structs (or
+// of pointers to structs). Items are appended shard by shard, each in
+// RANGE order. A throttled page is retried as the RetryPolicy allows.
+func QueryShards(ctx context.Context, svc dynamodbiface.DynamoDBAPI, b *QueryBuilder, out interface{}) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return &InvalidDecodeError{reflect.TypeOf(out)}
+	}
+	ins, err := b.ShardInputs()
+	if err != nil {
+		return err
+	}
+	et := rv.Elem().Type().Elem()
+	for _, in := range ins {
+		items, err := queryPages(ctx, svc, in, et)
+		if err != nil {
+			return err
+		}
+		rv.Elem().Set(reflect.Append(rv.Elem(), items...))
+	}
+	return nil
+}
+
+// reads every page of the query in, decoding the items to type et, as
+// scanSegment does for a scan
+func queryPages(ctx context.Context, svc dynamodbiface.DynamoDBAPI, in *dynamodb.QueryInput, et reflect.Type) ([]reflect.Value, error) {
+	var items []reflect.Value
+	p := std.retryPolicy()
+	for attempt := 1; ; {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		resp, err := svc.QueryWithContext(ctx, in)
+		if isThrottled(err) && attempt < p.attempts() {
+			if err := p.wait(ctx, attempt); err != nil {
+				return nil, err
+			}
+			attempt++
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		attempt = 1
+		for _, item := range resp.Items {
+			ev, err := newItem(item, et)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, ev)
+		}
+		if len(resp.LastEvaluatedKey) == 0 {
+			return items, nil
+		}
+		in.ExclusiveStartKey = resp.LastEvaluatedKey
+	}
+}
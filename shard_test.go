@@ -0,0 +1,133 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+type Click struct {
+	UserId string `dynaGo:",HASH,shards=8"`
+	Seq    int64  `dynaGo:",RANGE"`
+	Kind   string
+}
+
+func TestShardDistribution(t *testing.T) {
+	counts := make(map[int]int)
+	for seq := int64(0); seq < 200; seq++ {
+		e := Click{UserId: "user#123", Seq: seq, Kind: "click"}
+		item := Marshal(e).Item
+		s := *item["UserId"].S
+		if !strings.HasPrefix(s, "user#123#") {
+			t.Fatalf("failed: key %s", s)
+		}
+		shard, err := strconv.Atoi(strings.TrimPrefix(s, "user#123#"))
+		if err != nil || shard < 0 || shard >= 8 {
+			t.Fatalf("failed: shard of key %s", s)
+		}
+		counts[shard]++
+
+		km, err := KeyMap(e)
+		if err != nil {
+			t.Fatalf("failed: %s", err)
+		}
+		if *km["UserId"].S != s {
+			t.Errorf("failed: KeyMap %s, item %s", *km["UserId"].S, s)
+		}
+		var got Click
+		if err := Unmarshal(item, &got); err != nil {
+			t.Fatalf("failed: %s", err)
+		}
+		if got != e {
+			t.Errorf("failed: round trip %+v", got)
+		}
+	}
+	if len(counts) != 8 {
+		t.Errorf("failed: items spread over %d of 8 shards, %v", len(counts), counts)
+	}
+
+	ins, err := BatchWriteItems([]Click{{UserId: "user#123", Seq: 1}, {UserId: "user#123", Seq: 2}})
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	for _, wr := range ins[0].RequestItems[TableName(reflect.TypeOf(Click{}))] {
+		item := wr.PutRequest.Item
+		var c Click
+		if err := Unmarshal(item, &c); err != nil {
+			t.Fatalf("failed: %s", err)
+		}
+		if want := *Marshal(c).Item["UserId"].S; *item["UserId"].S != want {
+			t.Errorf("failed: batch wrote key %s, want %s", *item["UserId"].S, want)
+		}
+	}
+
+	type Unranged struct {
+		Id string `dynaGo:",HASH,shards=4"`
+	}
+	if err := Validate(Unranged{}); err == nil {
+		t.Error("failed: expected TagOptionError sharding without a RANGE")
+	}
+}
+
+func TestQueryShards(t *testing.T) {
+	svc := &stubDynamo{}
+	for shard := 0; shard < 8; shard++ {
+		svc.queryOut = append(svc.queryOut, &dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{
+			Marshal(Click{UserId: "user#123", Seq: int64(shard)}).Item,
+		}})
+	}
+	var clicks []Click
+	if err := QueryShards(context.Background(), svc, Query(Click{UserId: "user#123"}), &clicks); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if len(svc.queryIn) != 8 || len(clicks) != 8 {
+		t.Fatalf("failed: %d queries, %d clicks", len(svc.queryIn), len(clicks))
+	}
+	for shard, in := range svc.queryIn {
+		if s := *in.ExpressionAttributeValues[":v0"].S; s != "user#123#"+strconv.Itoa(shard) {
+			t.Errorf("failed: query %d of partition %s", shard, s)
+		}
+	}
+	for _, e := range clicks {
+		if e.UserId != "user#123" {
+			t.Errorf("failed: decoded %+v", e)
+		}
+	}
+}
+
+func TestShardInputsGlobalIndex(t *testing.T) {
+	type GroupClick struct {
+		UserId string `dynaGo:",HASH,shards=4"`
+		Seq    int64  `dynaGo:",RANGE"`
+		Group  int64  `dynaGo:",HASH=ByGroup"`
+		At     int64  `dynaGo:",RANGE=ByGroup"`
+		Kind   string `dynaGo:",HASH=ByKind"`
+		KindAt int64  `dynaGo:",RANGE=ByKind"`
+	}
+	c := GroupClick{UserId: "u1", Seq: 3, Group: 7, Kind: "view"}
+	ins, err := Query(c).UsingRange("At").ShardInputs()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if len(ins) != 1 || *ins[0].ExpressionAttributeValues[":v0"].N != "7" {
+		t.Errorf("failed: int index partition %v", ins)
+	}
+	ins, err = Query(c).UsingRange("KindAt").ShardInputs()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if len(ins) != 1 || *ins[0].ExpressionAttributeValues[":v0"].S != "view" {
+		t.Errorf("failed: string index partition %v", ins)
+	}
+	if ins, err := Query(c).ShardInputs(); err != nil || len(ins) != 4 {
+		t.Errorf("failed: table partition %d inputs, %v", len(ins), err)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// MaxItemSize is the largest item, in bytes, dynamoDB will store
+const MaxItemSize = 400 * 1024
+
+// ItemSize marshals the struct i and returns the size in bytes dynamoDB
+// counts against the 400KB item limit (and bills capacity by). The size
+// is the sum, over the attributes of the item, of the UTF-8 length of the
+// attribute name plus the size of its value, where
+//
+//	S       UTF-8 length of the string
+//	N       1 byte per 2 significant digits (rounded up), plus 1 byte
+//	B       length of the bytes
+//	BOOL    1 byte
+//	NULL    1 byte
+//	SS/NS/BS  sum of the sizes of the elements
+//	L       3 bytes, plus 1 byte and the size of each element
+//	M       3 bytes, plus 1 byte, the name and the size of each element
+//
+// dynamoDB documents the size of a number as approximate, so the result
+// is an estimate, accurate for the other types.
+func ItemSize(i interface{}) (int, error) {
+	pi, err := std.marshal(i)
+	if err != nil {
+		return 0, err
+	}
+	return itemSize(pi.Item), nil
+}
+
+func itemSize(item map[string]*dynamodb.AttributeValue) int {
+	size := 0
+	for an, av := range item {
+		size += len(an) + attributeSize(av)
+	}
+	return size
+}
+
+func attributeSize(av *dynamodb.AttributeValue) int {
+	switch {
+	case av.S != nil:
+		return len(*av.S)
+	case av.N != nil:
+		return numberSize(*av.N)
+	case av.B != nil:
+		return len(av.B)
+	case av.BOOL != nil, av.NULL != nil:
+		return 1
+	case av.SS != nil:
+		size := 0
+		for _, s := range av.SS {
+			size += len(*s)
+		}
+		return size
+	case av.NS != nil:
+		size := 0
+		for _, n := range av.NS {
+			size += numberSize(*n)
+		}
+		return size
+	case av.BS != nil:
+		size := 0
+		for _, b := range av.BS {
+			size += len(b)
+		}
+		return size
+	case av.L != nil:
+		size := 3
+		for _, e := range av.L {
+			size += 1 + attributeSize(e)
+		}
+		return size
+	case av.M != nil:
+		size := 3
+		for n, e := range av.M {
+			size += 1 + len(n) + attributeSize(e)
+		}
+		return size
+	}
+	return 0
+}
+
+// the significant digits of a number are those left once the sign,
+// point and exponent are dropped and leading and trailing zeroes trimmed
+func numberSize(n string) int {
+	if i := strings.IndexAny(n, "eE"); i != -1 {
+		n = n[:i]
+	}
+	n = strings.TrimLeft(n, "+-")
+	n = strings.Replace(n, ".", "", 1)
+	n = strings.Trim(n, "0")
+	return (len(n)+1)/2 + 1
+}
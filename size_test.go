@@ -0,0 +1,63 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"testing"
+)
+
+type Sized struct {
+	Id    string `dynaGo:",HASH"`
+	Count int
+	Data  []byte
+	Tags  []string
+	On    bool
+}
+
+func TestItemSize(t *testing.T) {
+	// ints and bools are stored even when zero, "Count" + 0 and "On" +
+	// false add 6 and 3 bytes to every item
+	tests := []struct {
+		in   Sized
+		size int
+	}{
+		// "Id" + "abc"
+		{Sized{Id: "abc"}, 2 + 3 + 6 + 3},
+		// 12345 is 5 digits: 3 + 1
+		{Sized{Id: "abc", Count: 12345}, 5 + 5 + 4 + 3},
+		// 1000 trims to a single significant digit
+		{Sized{Id: "abc", Count: 1000}, 5 + 5 + 2 + 3},
+		// + "Data" + 10 bytes, "Tags" + "ab" + "cde"
+		{Sized{Id: "abc", Data: make([]byte, 10), Tags: []string{"ab", "cde"}, On: true},
+			5 + 6 + 4 + 10 + 4 + 5 + 3},
+	}
+	for _, tt := range tests {
+		size, err := ItemSize(tt.in)
+		if err != nil {
+			t.Fatalf("failed: %s", err)
+		}
+		if size != tt.size {
+			t.Errorf("failed: size of %+v is %d, want %d", tt.in, size, tt.size)
+		}
+	}
+	if _, err := ItemSize(42); err == nil {
+		t.Error("failed: expected error sizing a non-struct")
+	}
+}
+
+func TestPutCheckSize(t *testing.T) {
+	s := Sized{Id: "big", Data: make([]byte, MaxItemSize)}
+	if _, err := Put(s).Input(); err != nil {
+		t.Errorf("failed: unchecked put returned %s", err)
+	}
+	_, err := Put(s).CheckSize().Input()
+	if tl, ok := err.(*ItemTooLargeError); !ok || tl.Size <= MaxItemSize {
+		t.Errorf("failed: expected ItemTooLargeError, got %v", err)
+	}
+	s.Data = s.Data[:MaxItemSize-100]
+	if _, err := Put(&s).CheckSize().Input(); err != nil {
+		t.Errorf("failed: item under the limit returned %s", err)
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+)
+
+// UnmarshalStreamRecord decodes the images of a DynamoDB Streams record
+// into the structs newImage and oldImage, so stream consumers can reuse
+// the same tagged models used to read the table. Either destination may
+// be nil to skip that image, and an image missing from the record (as
+// OldImage is for an INSERT, or NewImage for a REMOVE) leaves its
+// destination untouched.
+//
+// No type conversion is necessary: aws-sdk-go's dynamodbstreams package
+// declares NewImage and OldImage as map[string]*dynamodb.AttributeValue,
+// the same type GetItem returns. Images from other sources (such as the
+// events in aws-lambda-go) use their own AttributeValue type and must be
+// converted to the dynamodb type by the caller before decoding.
+func UnmarshalStreamRecord(r *dynamodbstreams.StreamRecord, newImage, oldImage interface{}) error {
+	if newImage != nil && r.NewImage != nil {
+		if err := Unmarshal(r.NewImage, newImage); err != nil {
+			return err
+		}
+	}
+	if oldImage != nil && r.OldImage != nil {
+		if err := Unmarshal(r.OldImage, oldImage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+)
+
+func TestUnmarshalStreamRecord(t *testing.T) {
+	r := &dynamodbstreams.StreamRecord{
+		NewImage: map[string]*dynamodb.AttributeValue{
+			"SessionId": {S: aws.String("abc")},
+			"Timestamp": {N: aws.String("1234")},
+			"MessageId": {S: aws.String("2unique")},
+			"Origin": {M: map[string]*dynamodb.AttributeValue{
+				"1000": {S: aws.String("192.168.2.1")},
+			}},
+			"Body": {S: aws.String("it's sweat, what you smell is sweat.")},
+		},
+		OldImage: map[string]*dynamodb.AttributeValue{
+			"SessionId": {S: aws.String("abc")},
+			"Timestamp": {N: aws.String("1234")},
+			"Body":      {S: aws.String("it's sweat.")},
+		},
+	}
+	want := Message{
+		SessId:    "abc",
+		Timestamp: 1234,
+		Id:        "2unique",
+		Origin:    map[string]string{"1000": "192.168.2.1"},
+		Body:      "it's sweat, what you smell is sweat.",
+	}
+	var n, o Message
+	if err := UnmarshalStreamRecord(r, &n, &o); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if !reflect.DeepEqual(n, want) {
+		t.Errorf("failed: decoded NewImage\n\t%+v\nwant\n\t%+v", n, want)
+	}
+	if o.Body != "it's sweat." {
+		t.Errorf("failed: decoded OldImage body %q", o.Body)
+	}
+}
+
+func TestUnmarshalStreamRecordMissingImage(t *testing.T) {
+	r := &dynamodbstreams.StreamRecord{
+		OldImage: map[string]*dynamodb.AttributeValue{
+			"SessionId": {S: aws.String("abc")},
+		},
+	}
+	n := Message{Body: "untouched"}
+	if err := UnmarshalStreamRecord(r, &n, nil); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if n.Body != "untouched" || n.SessId != "" {
+		t.Errorf("failed: absent NewImage modified destination: %+v", n)
+	}
+}
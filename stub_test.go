@@ -0,0 +1,223 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/applicationautoscaling"
+	"github.com/aws/aws-sdk-go/service/applicationautoscaling/applicationautoscalingiface"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// stubDynamo stands in for *dynamodb.DynamoDB in tests which don't need
+// a running dynamoDB. Each call records its input and replays the next
+// canned output, methods which aren't overridden panic when called.
+type stubDynamo struct {
+	dynamodbiface.DynamoDBAPI
+
+	scanIn  []*dynamodb.ScanInput
+	scanOut []*dynamodb.ScanOutput
+
+	// pages of each segment of a parallel scan, and failures by segment
+	mu         sync.Mutex
+	segmentOut map[int64][]*dynamodb.ScanOutput
+	segmentErr map[int64]error
+
+	queryIn  []*dynamodb.QueryInput
+	queryOut []*dynamodb.QueryOutput
+
+	// tables by name, for ListTables and DescribeTable
+	tables  map[string]*dynamodb.TableDescription
+	created []*dynamodb.CreateTableInput
+	updated []*dynamodb.UpdateTableInput
+
+	// batch writes received, and the requests to leave unprocessed in
+	// the reply to each
+	batchIn          []*dynamodb.BatchWriteItemInput
+	batchUnprocessed []map[string][]*dynamodb.WriteRequest
+
+	// batch gets received, and how many of the last keys of each to
+	// leave unprocessed in the reply
+	batchGetIn          []*dynamodb.BatchGetItemInput
+	batchGetUnprocessed []int
+
+	putIn    []*dynamodb.PutItemInput
+	updateIn []*dynamodb.UpdateItemInput
+
+	getIn []*dynamodb.GetItemInput
+	// items held by table name, matched against GetItem keys
+	items map[string][]map[string]*dynamodb.AttributeValue
+}
+
+func (s *stubDynamo) Scan(in *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+	c := *in
+	s.scanIn = append(s.scanIn, &c)
+	out := s.scanOut[0]
+	s.scanOut = s.scanOut[1:]
+	return out, nil
+}
+
+func (s *stubDynamo) ScanWithContext(ctx aws.Context, in *dynamodb.ScanInput, opts ...request.Option) (*dynamodb.ScanOutput, error) {
+	if in.Segment == nil {
+		return s.Scan(in)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := *in
+	s.scanIn = append(s.scanIn, &c)
+	if err := s.segmentErr[*in.Segment]; err != nil {
+		return nil, err
+	}
+	out := s.segmentOut[*in.Segment][0]
+	s.segmentOut[*in.Segment] = s.segmentOut[*in.Segment][1:]
+	return out, nil
+}
+
+func (s *stubDynamo) Query(in *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+	// copy, callers reuse the input for the next page
+	c := *in
+	s.queryIn = append(s.queryIn, &c)
+	out := s.queryOut[0]
+	s.queryOut = s.queryOut[1:]
+	return out, nil
+}
+
+func (s *stubDynamo) QueryWithContext(ctx aws.Context, in *dynamodb.QueryInput, opts ...request.Option) (*dynamodb.QueryOutput, error) {
+	return s.Query(in)
+}
+
+func (s *stubDynamo) GetItem(in *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+	s.getIn = append(s.getIn, in)
+	for _, item := range s.items[*in.TableName] {
+		if matchesKey(item, in.Key) {
+			return &dynamodb.GetItemOutput{Item: item}, nil
+		}
+	}
+	return &dynamodb.GetItemOutput{}, nil
+}
+
+func matchesKey(item, key map[string]*dynamodb.AttributeValue) bool {
+	for k, av := range key {
+		if item[k] == nil || item[k].String() != av.String() {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *stubDynamo) ListTables(in *dynamodb.ListTablesInput) (*dynamodb.ListTablesOutput, error) {
+	out := &dynamodb.ListTablesOutput{}
+	for tn := range s.tables {
+		n := tn
+		out.TableNames = append(out.TableNames, &n)
+	}
+	return out, nil
+}
+
+func (s *stubDynamo) DescribeTable(in *dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
+	return &dynamodb.DescribeTableOutput{Table: s.tables[*in.TableName]}, nil
+}
+
+func (s *stubDynamo) CreateTable(in *dynamodb.CreateTableInput) (*dynamodb.CreateTableOutput, error) {
+	s.created = append(s.created, in)
+	return &dynamodb.CreateTableOutput{}, nil
+}
+
+func (s *stubDynamo) UpdateTable(in *dynamodb.UpdateTableInput) (*dynamodb.UpdateTableOutput, error) {
+	s.updated = append(s.updated, in)
+	return &dynamodb.UpdateTableOutput{}, nil
+}
+
+func (s *stubDynamo) BatchWriteItemWithContext(ctx aws.Context, in *dynamodb.BatchWriteItemInput, opts ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+	s.batchIn = append(s.batchIn, in)
+	out := &dynamodb.BatchWriteItemOutput{}
+	if in.ReturnConsumedCapacity != nil {
+		// a unit for each request
+		for tn, rs := range in.RequestItems {
+			tn, units := tn, float64(len(rs))
+			out.ConsumedCapacity = append(out.ConsumedCapacity, &dynamodb.ConsumedCapacity{TableName: &tn, CapacityUnits: &units})
+		}
+	}
+	if len(s.batchUnprocessed) > 0 {
+		out.UnprocessedItems = s.batchUnprocessed[0]
+		s.batchUnprocessed = s.batchUnprocessed[1:]
+	}
+	return out, nil
+}
+
+func (s *stubDynamo) BatchGetItemWithContext(ctx aws.Context, in *dynamodb.BatchGetItemInput, opts ...request.Option) (*dynamodb.BatchGetItemOutput, error) {
+	s.batchGetIn = append(s.batchGetIn, in)
+	skip := 0
+	if len(s.batchGetUnprocessed) > 0 {
+		skip = s.batchGetUnprocessed[0]
+		s.batchGetUnprocessed = s.batchGetUnprocessed[1:]
+	}
+	out := &dynamodb.BatchGetItemOutput{Responses: make(map[string][]map[string]*dynamodb.AttributeValue)}
+	for tn, ka := range in.RequestItems {
+		n := len(ka.Keys) - skip
+		if skip > 0 {
+			out.UnprocessedKeys = map[string]*dynamodb.KeysAndAttributes{tn: {Keys: ka.Keys[n:]}}
+		}
+		for _, key := range ka.Keys[:n] {
+			for _, item := range s.items[tn] {
+				if matchesKey(item, key) {
+					out.Responses[tn] = append(out.Responses[tn], item)
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// PutItem adds the item to the table, failing a ConditionExpression
+// (assumed to be attribute_not_exists of the key) when an item with the
+// same key is held.
+func (s *stubDynamo) PutItem(in *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	s.putIn = append(s.putIn, in)
+	if ce := aws.StringValue(in.ConditionExpression); strings.HasPrefix(ce, "attribute_not_exists(") {
+		// the put of a new item, conditioned on its key attribute
+		kn := *in.ExpressionAttributeNames[strings.TrimSuffix(strings.TrimPrefix(ce, "attribute_not_exists("), ")")]
+		for _, item := range s.items[*in.TableName] {
+			if matchesKey(item, map[string]*dynamodb.AttributeValue{kn: in.Item[kn]}) {
+				return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "the conditional request failed", nil)
+			}
+		}
+	}
+	if s.items == nil {
+		s.items = make(map[string][]map[string]*dynamodb.AttributeValue)
+	}
+	s.items[*in.TableName] = append(s.items[*in.TableName], in.Item)
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (s *stubDynamo) UpdateItem(in *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+	s.updateIn = append(s.updateIn, in)
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+// stubAutoScaling stands in for *applicationautoscaling.ApplicationAutoScaling,
+// recording the targets and policies registered.
+type stubAutoScaling struct {
+	applicationautoscalingiface.ApplicationAutoScalingAPI
+
+	targets  []*applicationautoscaling.RegisterScalableTargetInput
+	policies []*applicationautoscaling.PutScalingPolicyInput
+}
+
+func (s *stubAutoScaling) RegisterScalableTarget(in *applicationautoscaling.RegisterScalableTargetInput) (*applicationautoscaling.RegisterScalableTargetOutput, error) {
+	s.targets = append(s.targets, in)
+	return &applicationautoscaling.RegisterScalableTargetOutput{}, nil
+}
+
+func (s *stubAutoScaling) PutScalingPolicy(in *applicationautoscaling.PutScalingPolicyInput) (*applicationautoscaling.PutScalingPolicyOutput, error) {
+	s.policies = append(s.policies, in)
+	return &applicationautoscaling.PutScalingPolicyOutput{}, nil
+}
@@ -44,3 +44,23 @@ func (o tagOptions) Contains(optionName string) bool {
 	}
 	return false
 }
+
+// Values returns the value of every "optionName=value" option in the
+// list, in the order they appear. These are a dynaGo addition to the
+// options of encoding/json.
+func (o tagOptions) Values(optionName string) []string {
+	var vs []string
+	s := string(o)
+	for s != "" {
+		var next string
+		i := strings.Index(s, ",")
+		if i >= 0 {
+			s, next = s[:i], s[i+1:]
+		}
+		if strings.HasPrefix(s, optionName+"=") {
+			vs = append(vs, s[len(optionName)+1:])
+		}
+		s = next
+	}
+	return vs
+}
@@ -29,3 +29,16 @@ func TestTagParsing(t *testing.T) {
 		}
 	}
 }
+
+func TestTagValues(t *testing.T) {
+	_, opts := parseTag("field,HASH,RANGE=ByDate,HASH=ByName,RANGE=ByOwner")
+	if vs := opts.Values("RANGE"); len(vs) != 2 || vs[0] != "ByDate" || vs[1] != "ByOwner" {
+		t.Errorf("Values(RANGE) = %q", vs)
+	}
+	if vs := opts.Values("HASH"); len(vs) != 1 || vs[0] != "ByName" {
+		t.Errorf("Values(HASH) = %q", vs)
+	}
+	if vs := opts.Values("foo"); vs != nil {
+		t.Errorf("Values(foo) = %q", vs)
+	}
+}
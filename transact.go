@@ -0,0 +1,154 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// MaxTransactItems is the most items a single transaction may address
+const MaxTransactItems = 25
+
+// MaxClientRequestTokenLen is the longest ClientRequestToken dynamoDB
+// accepts for a transaction
+const MaxClientRequestTokenLen = 36
+
+// TransactGet builds a TransactGetItemsInput reading the items with the
+// keys of keys (structs, or pointers to structs, of any mix of types),
+// in the order given. A transaction is read atomically, so it isn't
+// split: more than MaxTransactItems keys is a TransactionSizeError, and
+// the caller must decide where consistency may be given up.
+func TransactGet(keys ...interface{}) (*dynamodb.TransactGetItemsInput, error) {
+	if len(keys) > MaxTransactItems {
+		return nil, &TransactionSizeError{len(keys)}
+	}
+	items := make([]*dynamodb.TransactGetItem, len(keys))
+	for n, k := range keys {
+		gi, err := Get(k).Input()
+		if err != nil {
+			return nil, err
+		}
+		items[n] = &dynamodb.TransactGetItem{Get: &dynamodb.Get{
+			TableName: gi.TableName,
+			Key:       gi.Key,
+		}}
+	}
+	return &dynamodb.TransactGetItemsInput{TransactItems: items}, nil
+}
+
+// TransactWriteBuilder assembles a dynamodb.TransactWriteItemsInput from
+// the inputs of Put, Update and Delete builders.
+type TransactWriteBuilder struct {
+	writes []interface{}
+	token  string
+}
+
+// TransactWrite begins a TransactWriteItemsInput applying writes, each a
+// *PutBuilder, *UpdateBuilder or *DeleteBuilder, atomically and in the
+// order given. Their If conditions carry over to the transaction, their
+// ReturnValues and ReturnConsumedCapacity don't. As with TransactGet a
+// transaction isn't split: more than MaxTransactItems writes is a
+// TransactionSizeError.
+func TransactWrite(writes ...interface{}) *TransactWriteBuilder {
+	return &TransactWriteBuilder{writes: writes}
+}
+
+// ClientRequestToken makes the transaction idempotent: dynamoDB applies
+// the writes of calls made with the same token (within ten minutes) only
+// once, so a call retried after a network failure can't apply them
+// twice. The token must be 1 to MaxClientRequestTokenLen characters long.
+// TransactGetItems takes no token, reads being idempotent already.
+func (b *TransactWriteBuilder) ClientRequestToken(token string) *TransactWriteBuilder {
+	b.token = token
+	return b
+}
+
+// Input builds the TransactWriteItemsInput
+func (b *TransactWriteBuilder) Input() (*dynamodb.TransactWriteItemsInput, error) {
+	if len(b.writes) > MaxTransactItems {
+		return nil, &TransactionSizeError{len(b.writes)}
+	}
+	if len(b.token) > MaxClientRequestTokenLen {
+		return nil, &ClientRequestTokenError{b.token}
+	}
+	items := make([]*dynamodb.TransactWriteItem, len(b.writes))
+	for n, w := range b.writes {
+		item, err := transactWriteItem(w)
+		if err != nil {
+			return nil, err
+		}
+		items[n] = item
+	}
+	in := &dynamodb.TransactWriteItemsInput{TransactItems: items}
+	if b.token != "" {
+		in.ClientRequestToken = &b.token
+	}
+	return in, nil
+}
+
+// the TransactWriteItem of the builder w
+func transactWriteItem(w interface{}) (*dynamodb.TransactWriteItem, error) {
+	switch b := w.(type) {
+	case *PutBuilder:
+		pi, err := b.Input()
+		if err != nil {
+			return nil, err
+		}
+		return &dynamodb.TransactWriteItem{Put: &dynamodb.Put{
+			TableName:                 pi.TableName,
+			Item:                      pi.Item,
+			ConditionExpression:       pi.ConditionExpression,
+			ExpressionAttributeNames:  pi.ExpressionAttributeNames,
+			ExpressionAttributeValues: pi.ExpressionAttributeValues,
+		}}, nil
+	case *UpdateBuilder:
+		ui, err := b.Input()
+		if err != nil {
+			return nil, err
+		}
+		return &dynamodb.TransactWriteItem{Update: &dynamodb.Update{
+			TableName:                 ui.TableName,
+			Key:                       ui.Key,
+			UpdateExpression:          ui.UpdateExpression,
+			ConditionExpression:       ui.ConditionExpression,
+			ExpressionAttributeNames:  ui.ExpressionAttributeNames,
+			ExpressionAttributeValues: ui.ExpressionAttributeValues,
+		}}, nil
+	case *DeleteBuilder:
+		di, err := b.Input()
+		if err != nil {
+			return nil, err
+		}
+		return &dynamodb.TransactWriteItem{Delete: &dynamodb.Delete{
+			TableName:                 di.TableName,
+			Key:                       di.Key,
+			ConditionExpression:       di.ConditionExpression,
+			ExpressionAttributeNames:  di.ExpressionAttributeNames,
+			ExpressionAttributeValues: di.ExpressionAttributeValues,
+		}}, nil
+	}
+	return nil, &UnsupportedWriteError{reflect.TypeOf(w)}
+}
+
+// UnmarshalTransactGet decodes the responses of a TransactGetItems call
+// into dests, pointers to structs given in the same order as the keys
+// passed to TransactGet. A dest whose item doesn't exist is left
+// untouched.
+func UnmarshalTransactGet(out *dynamodb.TransactGetItemsOutput, dests ...interface{}) error {
+	if len(out.Responses) != len(dests) {
+		return &ResponseCountError{len(out.Responses), len(dests)}
+	}
+	for n, r := range out.Responses {
+		if r == nil || r.Item == nil {
+			continue
+		}
+		if err := Unmarshal(r.Item, dests[n]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
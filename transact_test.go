@@ -0,0 +1,96 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestTransactGet(t *testing.T) {
+	o := Order{Customer: "c1", OrderId: "o1"}
+	in, err := TransactGet(&o, Usr{Id: "1000"})
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if len(in.TransactItems) != 2 {
+		t.Fatalf("failed: %d items", len(in.TransactItems))
+	}
+	g0, g1 := in.TransactItems[0].Get, in.TransactItems[1].Get
+	if *g0.TableName != TableName(reflect.TypeOf(o)) || *g0.Key["OrderId"].S != "o1" {
+		t.Errorf("failed: first get %v", g0)
+	}
+	if *g1.TableName != TableName(reflect.TypeOf(usr0)) || *g1.Key["UserId"].S != "1000" {
+		t.Errorf("failed: second get %v", g1)
+	}
+
+	out := &dynamodb.TransactGetItemsOutput{Responses: []*dynamodb.ItemResponse{
+		{Item: Marshal(Order{Customer: "c1", OrderId: "o1", Total: 12}).Item},
+		{},
+	}}
+	var oo Order
+	u := Usr{Alias: "untouched"}
+	if err := UnmarshalTransactGet(out, &oo, &u); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if oo.Total != 12 || u.Alias != "untouched" {
+		t.Errorf("failed: decoded %+v %+v", oo, u)
+	}
+	if err := UnmarshalTransactGet(out, &oo); err == nil {
+		t.Error("failed: expected error for too few destinations")
+	}
+
+	keys := make([]interface{}, MaxTransactItems+1)
+	for n := range keys {
+		keys[n] = o
+	}
+	if _, err := TransactGet(keys...); err == nil {
+		t.Error("failed: expected TransactionSizeError")
+	}
+}
+
+func TestTransactWriteToken(t *testing.T) {
+	o := Order{Customer: "c1", OrderId: "o1", Total: 12}
+	in, err := TransactWrite(
+		Put(o).If(Filter("OrderId", "attribute_not_exists", nil)),
+		Update(Usr{Id: "1000", Alias: "bob"}).FieldMask("Alias"),
+		Delete(Usr{Id: "1001"}),
+	).ClientRequestToken("order-o1-attempt").Input()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if in.ClientRequestToken == nil || *in.ClientRequestToken != "order-o1-attempt" {
+		t.Errorf("failed: token %v", in.ClientRequestToken)
+	}
+	if len(in.TransactItems) != 3 {
+		t.Fatalf("failed: %d items", len(in.TransactItems))
+	}
+	p, u, d := in.TransactItems[0].Put, in.TransactItems[1].Update, in.TransactItems[2].Delete
+	if p == nil || *p.ConditionExpression != "attribute_not_exists(#n0)" || *p.Item["OrderId"].S != "o1" {
+		t.Errorf("failed: put %v", p)
+	}
+	if u == nil || *u.UpdateExpression != "SET #n0 = :v0" || *u.Key["UserId"].S != "1000" {
+		t.Errorf("failed: update %v", u)
+	}
+	if d == nil || *d.Key["UserId"].S != "1001" {
+		t.Errorf("failed: delete %v", d)
+	}
+
+	if in, err := TransactWrite(Delete(o)).Input(); err != nil || in.ClientRequestToken != nil {
+		t.Errorf("failed: expected no token, got %v, %v", in, err)
+	}
+	long := make([]byte, MaxClientRequestTokenLen+1)
+	for n := range long {
+		long[n] = 'a'
+	}
+	if _, err := TransactWrite(Delete(o)).ClientRequestToken(string(long)).Input(); err == nil {
+		t.Error("failed: expected ClientRequestTokenError")
+	}
+	if _, err := TransactWrite(o).Input(); err == nil {
+		t.Error("failed: expected UnsupportedWriteError")
+	}
+}
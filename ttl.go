@@ -0,0 +1,102 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// A field tagged `dynaGo:",TTL"` holds the expiry of its item, for the
+// table's time to live attribute. It is stored as an N of epoch seconds,
+// which dynamoDB's TTL requires, whether it is a time.Time (losing any
+// fraction of a second) or an integer already holding epoch seconds, or
+// a pointer to either. A zero or nil TTL is left out, or set from the
+// default TTL of the Encoder (see Encoder.SetDefaultTTL) if it has one.
+// A struct has at most one TTL field, of one of these types, and it
+// can't be a key of the table; anything else panics with a
+// TagOptionError.
+func isTTL(sf reflect.StructField) bool {
+	_, o := parseTag(sf.Tag.Get("dynaGo"))
+	return o.Contains("TTL")
+}
+
+// panics with a TagOptionError if the TTL field sf can't be one
+func checkTTL(sf reflect.StructField) {
+	t := sf.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		if t != durationType && !isKeyField(sf) {
+			return
+		}
+	case reflect.Struct:
+		if t == timeType && !isKeyField(sf) {
+			return
+		}
+	}
+	panic(&TagOptionError{sf.Name, "TTL"})
+}
+
+// encodes the TTL field v as epoch seconds, leaving out zero
+func ttlValueEncoder(e *valueEncoderState, n string, v reflect.Value) string {
+	var secs int64
+	switch {
+	case v.Type() == timeType:
+		if t := v.Interface().(time.Time); !t.IsZero() {
+			secs = t.Unix()
+		}
+	case v.Kind() >= reflect.Uint && v.Kind() <= reflect.Uint64:
+		secs = int64(v.Uint())
+	default:
+		secs = v.Int()
+	}
+	if secs == 0 {
+		return ""
+	}
+	str := strconv.FormatInt(secs, 10)
+	if e != nil {
+		e.item[n] = numberAttribute(str)
+	}
+	return str
+}
+
+// decodes a time.Time TTL field from epoch seconds, or from the S it
+// would have been stored as before being tagged TTL
+func ttlTimeDecoder(av *dynamodb.AttributeValue, rv reflect.Value) {
+	if av.S != nil {
+		timeDecoder(av, rv)
+		return
+	}
+	expectType(av, rv, "N", av.N != nil)
+	secs, err := strconv.ParseInt(*av.N, 10, 64)
+	if err != nil {
+		panic(InvalidNumberDecodeError{*av.N, rv.Type()})
+	}
+	rv.Set(reflect.ValueOf(time.Unix(secs, 0).UTC()))
+}
+
+// sets the TTL attribute of item, encoded from the struct type t, to
+// now+d if it was left out, when d is positive
+func fillTTL(item map[string]*dynamodb.AttributeValue, t reflect.Type, namer func(string) string, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	for _, fs := range attrFields(t) {
+		if !isTTL(fs) {
+			continue
+		}
+		an := attrName(fs, namer)
+		if _, ok := item[an]; !ok {
+			item[an] = numberAttribute(strconv.FormatInt(time.Now().Add(d).Unix(), 10))
+		}
+		return
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// UpdateBuilder assembles a dynamodb.UpdateItemInput from a struct. The
+// item is addressed by the key fields of the struct, and the remaining
+// fields are written with a single SET action, or removed with a REMOVE
+// action when they are nil pointers.
+type UpdateBuilder struct {
+	enc   *Encoder
+	i     interface{}
+	mask  []string
+	rv    string
+	rcc   string
+	conds []Condition
+	paths []string
+}
+
+// Update begins an UpdateItemInput for the struct (or pointer to struct) i
+func Update(i interface{}) *UpdateBuilder {
+	return std.Update(i)
+}
+
+// FieldMask restricts the update to the named Go fields, so only their
+// attributes are written whether or not other fields are non-zero. This
+// allows a partially populated struct to update just the attributes it
+// carries. Key fields cannot be masked, they are always used as the Key,
+// nor can readonly fields, which are never written.
+func (b *UpdateBuilder) FieldMask(fields ...string) *UpdateBuilder {
+	b.mask = append(b.mask, fields...)
+	return b
+}
+
+// ReturnValues asks dynamoDB to return attributes of the item as they
+// were before (ALL_OLD, UPDATED_OLD) or after (ALL_NEW, UPDATED_NEW) the
+// update, or none at all (NONE, the default). Decode the returned
+// attributes with UnmarshalReturnValues.
+func (b *UpdateBuilder) ReturnValues(rv string) *UpdateBuilder {
+	b.rv = rv
+	return b
+}
+
+// ReturnConsumedCapacity asks dynamoDB to report the capacity the update
+// consumes, TOTAL or by INDEXES, or NONE (the default).
+func (b *UpdateBuilder) ReturnConsumedCapacity(rcc string) *UpdateBuilder {
+	b.rcc = rcc
+	return b
+}
+
+// Remove removes the attribute at each document path of paths from the
+// item, a nested attribute (Address.Zip) or the element of a list
+// (Items[2]), see pathSegment. The REMOVE aliases each segment, and a
+// path which doesn't parse fails Input with a DocumentPathError. An
+// update may not both set an attribute and remove a path within it, so
+// use FieldMask to leave out the field holding the path.
+func (b *UpdateBuilder) Remove(paths ...string) *UpdateBuilder {
+	b.paths = append(b.paths, paths...)
+	return b
+}
+
+// If conditions the update on the AND of conds holding for the item,
+// see ApplyCondition.
+func (b *UpdateBuilder) If(conds ...Condition) *UpdateBuilder {
+	b.conds = append(b.conds, conds...)
+	return b
+}
+
+// Input builds the UpdateItemInput. Attribute names and values are
+// always aliased (#n0, :v0, ...) so reserved words are safe to use as
+// attribute names. Pointer fields which are nil are removed from the
+// item, so nulling out an optional field clears its stored value. Other
+// fields which encode to nothing (empty strings, nil maps, empty slices)
+// are left out of the update.
+func (b *UpdateBuilder) Input() (in *dynamodb.UpdateItemInput, err error) {
+	defer recoverError(&err)
+	if err := checkReturnValues("UpdateItem", b.rv, dynamodb.ReturnValueAllOld,
+		dynamodb.ReturnValueUpdatedOld, dynamodb.ReturnValueAllNew, dynamodb.ReturnValueUpdatedNew); err != nil {
+		return nil, err
+	}
+	if err := checkConsumedCapacity(b.rcc); err != nil {
+		return nil, err
+	}
+	key, err := b.enc.KeyMap(b.i)
+	if err != nil {
+		return nil, err
+	}
+	v := reflect.Indirect(reflect.ValueOf(b.i))
+	t := v.Type()
+	fs, err := b.fields(t)
+	if err != nil {
+		return nil, err
+	}
+	e := &valueEncoderState{make(map[string]*dynamodb.AttributeValue), b.enc.namer}
+	names := make(map[string]*string)
+	values := make(map[string]*dynamodb.AttributeValue)
+	sets := make([]string, 0, len(fs))
+	var removes []string
+	for _, sf := range fs {
+		fv, ok := fieldByIndex(v, sf.Index)
+		if !ok {
+			continue
+		}
+		an := attrName(sf, b.enc.namer)
+		n := strconv.Itoa(len(names))
+		if fv.Kind() == reflect.Ptr && fv.IsNil() {
+			names["#n"+n] = &an
+			removes = append(removes, "#n"+n)
+			continue
+		}
+		fieldValueEncoder(sf)(e, an, fv)
+		av, ok := e.item[an]
+		if !ok {
+			continue
+		}
+		if isEncrypted(sf) {
+			av = encryptAttribute(sf, an, av, b.enc.cipher)
+		}
+		names["#n"+n] = &an
+		values[":v"+n] = av
+		sets = append(sets, "#n"+n+" = :v"+n)
+	}
+	if len(b.paths) > 0 {
+		x := newExpression(t, b.enc.namer, names, nil)
+		for _, p := range b.paths {
+			ap, err := x.path(p)
+			if err != nil {
+				return nil, err
+			}
+			removes = append(removes, ap)
+		}
+		names = x.names
+	}
+	tn := b.enc.TableName(t)
+	in = &dynamodb.UpdateItemInput{
+		TableName: &tn,
+		Key:       key,
+	}
+	if b.rv != "" {
+		in.ReturnValues = &b.rv
+	}
+	if b.rcc != "" {
+		in.ReturnConsumedCapacity = &b.rcc
+	}
+	var actions []string
+	if len(sets) > 0 {
+		actions = append(actions, "SET "+strings.Join(sets, ", "))
+		in.ExpressionAttributeValues = values
+	}
+	if len(removes) > 0 {
+		actions = append(actions, "REMOVE "+strings.Join(removes, ", "))
+	}
+	if len(actions) > 0 {
+		ue := strings.Join(actions, " ")
+		in.UpdateExpression = &ue
+		in.ExpressionAttributeNames = names
+	}
+	if err := applyCondition(in, b.i, b.enc.namer, b.conds); err != nil {
+		return nil, err
+	}
+	return in, nil
+}
+
+// the fields to be written, in declaration order when unmasked
+func (b *UpdateBuilder) fields(t reflect.Type) ([]reflect.StructField, error) {
+	fs := make([]reflect.StructField, 0, t.NumField())
+	if len(b.mask) == 0 {
+		for _, sf := range attrFields(t) {
+			if !isKeyField(sf) && !isReadOnly(sf) {
+				fs = append(fs, sf)
+			}
+		}
+		return fs, nil
+	}
+	for _, name := range b.mask {
+		sf, ok := t.FieldByName(name)
+		if !ok {
+			return nil, &UnknownFieldError{t, name}
+		}
+		if isKeyField(sf) {
+			return nil, &KeyFieldUpdateError{name}
+		}
+		if isReadOnly(sf) {
+			return nil, &ReadOnlyFieldError{name}
+		}
+		if _, _, ok := flattenedStruct(sf); ok {
+			fs = append(fs, flattenedFields(t, sf)...)
+			continue
+		}
+		fs = append(fs, sf)
+	}
+	return fs, nil
+}
+
+// the fields attrFields flattens from the struct field sf of t
+func flattenedFields(t reflect.Type, sf reflect.StructField) []reflect.StructField {
+	var fs []reflect.StructField
+	for _, af := range attrFields(t) {
+		if len(af.Index) > len(sf.Index) && reflect.DeepEqual(af.Index[:len(sf.Index)], sf.Index) {
+			fs = append(fs, af)
+		}
+	}
+	return fs
+}
@@ -0,0 +1,153 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUpdateFieldMask(t *testing.T) {
+	m := Message{
+		SessId:    "abc",
+		Timestamp: 1234,
+		Id:        "not-written",
+		Origin:    map[string]string{"1000": "192.168.2.1"},
+		Body:      "edited",
+	}
+	in, err := Update(m).FieldMask("Body", "Origin").Input()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if *in.TableName != TableName(reflect.TypeOf(m)) {
+		t.Errorf("failed: table name %s", *in.TableName)
+	}
+	if *in.Key["SessionId"].S != "abc" || *in.Key["Timestamp"].N != "1234" || len(in.Key) != 2 {
+		t.Errorf("failed: key %v", in.Key)
+	}
+	if *in.UpdateExpression != "SET #n0 = :v0, #n1 = :v1" {
+		t.Errorf("failed: update expression %q", *in.UpdateExpression)
+	}
+	if *in.ExpressionAttributeNames["#n0"] != "Body" || *in.ExpressionAttributeNames["#n1"] != "Origin" {
+		t.Errorf("failed: attribute names %v", in.ExpressionAttributeNames)
+	}
+	if len(in.ExpressionAttributeValues) != 2 ||
+		*in.ExpressionAttributeValues[":v0"].S != "edited" ||
+		*in.ExpressionAttributeValues[":v1"].M["1000"].S != "192.168.2.1" {
+		t.Errorf("failed: attribute values %v", in.ExpressionAttributeValues)
+	}
+}
+
+func TestUpdateFieldMaskErrors(t *testing.T) {
+	m := Message{SessId: "abc", Timestamp: 1234}
+	if _, err := Update(m).FieldMask("Nope").Input(); err == nil {
+		t.Error("failed: expected error for unknown field")
+	}
+	if _, err := Update(&m).FieldMask("Timestamp").Input(); err == nil {
+		t.Error("failed: expected error for masked key field")
+	}
+}
+
+func TestUpdateWithPrefix(t *testing.T) {
+	m := Message{SessId: "abc", Timestamp: 1234, Body: "moved"}
+	a, err := NewEncoder().WithPrefix("TENANT_A").Update(m).Input()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	b, err := NewEncoder().WithPrefix("TENANT_B").Update(m).Input()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if *a.TableName != "TENANT_A_Messages" || *b.TableName != "TENANT_B_Messages" {
+		t.Errorf("failed: table names %s %s", *a.TableName, *b.TableName)
+	}
+	if TableName(reflect.TypeOf(m)) != tablePrefix()+"Messages" {
+		t.Errorf("failed: default prefix changed to %s", TableName(reflect.TypeOf(m)))
+	}
+}
+
+func TestUpdateRemovesNilPointers(t *testing.T) {
+	type Profile struct {
+		Id       string `dynaGo:",HASH"`
+		Nickname *string
+		Age      *int
+	}
+	nick := "ace"
+	in, err := Update(Profile{Id: "p1", Nickname: &nick}).Input()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if *in.UpdateExpression != "SET #n0 = :v0 REMOVE #n1" {
+		t.Errorf("failed: update expression %q", *in.UpdateExpression)
+	}
+	if *in.ExpressionAttributeNames["#n0"] != "Nickname" || *in.ExpressionAttributeNames["#n1"] != "Age" {
+		t.Errorf("failed: attribute names %v", in.ExpressionAttributeNames)
+	}
+	if len(in.ExpressionAttributeValues) != 1 || *in.ExpressionAttributeValues[":v0"].S != "ace" {
+		t.Errorf("failed: attribute values %v", in.ExpressionAttributeValues)
+	}
+
+	in, err = Update(Profile{Id: "p1"}).Input()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if *in.UpdateExpression != "REMOVE #n0, #n1" || in.ExpressionAttributeValues != nil {
+		t.Errorf("failed: update expression %q values %v", *in.UpdateExpression, in.ExpressionAttributeValues)
+	}
+}
+
+func TestUpdateRemovePath(t *testing.T) {
+	type Address struct {
+		Street string
+		Zip    string `dynaGo:"zip"`
+	}
+	type Item struct {
+		Name string
+	}
+	type Cart struct {
+		Id      string `dynaGo:",HASH"`
+		Note    string
+		Address Address
+		Items   []Item `dynaGo:"items,list"`
+	}
+	c := Cart{Id: "c1", Note: "gift"}
+	in, err := Update(c).FieldMask("Note").Remove("Address.Zip", "Items[2]").Input()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if *in.UpdateExpression != "SET #n0 = :v0 REMOVE #n1.#n2, #n3[2]" {
+		t.Errorf("failed: update expression %q", *in.UpdateExpression)
+	}
+	ns := in.ExpressionAttributeNames
+	if *ns["#n0"] != "Note" || *ns["#n1"] != "Address" || *ns["#n2"] != "zip" || *ns["#n3"] != "items" {
+		t.Errorf("failed: attribute names %v", ns)
+	}
+
+	in, err = Update(c).FieldMask("Note").Remove("Items[0].Name").Input()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if *in.UpdateExpression != "SET #n0 = :v0 REMOVE #n1[0].#n2" || *in.ExpressionAttributeNames["#n2"] != "Name" {
+		t.Errorf("failed: update expression %q", *in.UpdateExpression)
+	}
+	for _, p := range []string{"Address..Zip", "Items[x]", "Address.Nope"} {
+		if _, err := Update(c).FieldMask("Note").Remove(p).Input(); err == nil {
+			t.Errorf("failed: expected error removing %q", p)
+		}
+	}
+
+	// struct fields along the path are named as the encoder names them
+	enc := NewEncoder()
+	enc.SetNameTransformer(strings.ToLower)
+	in, err = enc.Update(c).FieldMask("Note").Remove("Address.Street").Input()
+	if err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	ns = in.ExpressionAttributeNames
+	if *ns["#n0"] != "note" || *ns["#n1"] != "address" || *ns["#n2"] != "street" {
+		t.Errorf("failed: attribute names %v", ns)
+	}
+}
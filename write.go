@@ -0,0 +1,207 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// PutBuilder assembles a dynamodb.PutItemInput for a struct, as Marshal
+// does, with the options Marshal has no room for.
+type PutBuilder struct {
+	enc       *Encoder
+	i         interface{}
+	rv        string
+	rcc       string
+	checkSize bool
+	conds     []Condition
+}
+
+// Put begins a PutItemInput for the struct (or pointer to struct) i
+func Put(i interface{}) *PutBuilder {
+	return std.Put(i)
+}
+
+// ReturnValues asks dynamoDB to return the item as it was before the put,
+// either NONE (the default) or ALL_OLD. Decode the returned attributes
+// with UnmarshalReturnValues.
+func (b *PutBuilder) ReturnValues(rv string) *PutBuilder {
+	b.rv = rv
+	return b
+}
+
+// ReturnConsumedCapacity asks dynamoDB to report the capacity the put
+// consumes, TOTAL or by INDEXES, or NONE (the default).
+func (b *PutBuilder) ReturnConsumedCapacity(rcc string) *PutBuilder {
+	b.rcc = rcc
+	return b
+}
+
+// If conditions the put on the AND of conds holding for the item it
+// replaces, see ApplyCondition.
+func (b *PutBuilder) If(conds ...Condition) *PutBuilder {
+	b.conds = append(b.conds, conds...)
+	return b
+}
+
+// CheckSize has Input size the item (as ItemSize does) and fail with an
+// ItemTooLargeError when it exceeds MaxItemSize, rather than leaving
+// dynamoDB to reject the put. Sizing walks the whole item, so it's off
+// unless asked for.
+func (b *PutBuilder) CheckSize() *PutBuilder {
+	b.checkSize = true
+	return b
+}
+
+// Input builds the PutItemInput, returning encoding failures
+func (b *PutBuilder) Input() (*dynamodb.PutItemInput, error) {
+	if err := checkReturnValues("PutItem", b.rv, dynamodb.ReturnValueAllOld); err != nil {
+		return nil, err
+	}
+	if err := checkConsumedCapacity(b.rcc); err != nil {
+		return nil, err
+	}
+	pi, err := b.enc.marshal(b.i)
+	if err != nil {
+		return nil, err
+	}
+	if b.checkSize {
+		if size := itemSize(pi.Item); size > MaxItemSize {
+			return nil, &ItemTooLargeError{reflect.Indirect(reflect.ValueOf(b.i)).Type(), size}
+		}
+	}
+	if b.rv != "" {
+		pi.ReturnValues = &b.rv
+	}
+	if b.rcc != "" {
+		pi.ReturnConsumedCapacity = &b.rcc
+	}
+	if err := applyCondition(pi, b.i, b.enc.namer, b.conds); err != nil {
+		return nil, err
+	}
+	return pi, nil
+}
+
+// Upsert puts the struct i when no item with its key exists, and
+// otherwise updates just the attributes of the Go fields updateFields of
+// the existing item, as Update(i).FieldMask(updateFields...) would. With
+// no updateFields an existing item is left as it is.
+//
+// The put is conditioned on attribute_not_exists of the partition key,
+// and the update only sent when that condition fails, so a concurrent
+// put of the same key can't be overwritten. The two calls aren't one
+// transaction though: an item deleted between them is recreated by the
+// update holding only its key and the updated attributes.
+func Upsert(svc dynamodbiface.DynamoDBAPI, i interface{}, updateFields ...string) error {
+	return std.Upsert(svc, i, updateFields...)
+}
+
+// DeleteBuilder assembles a dynamodb.DeleteItemInput for the item with
+// the key of a struct.
+type DeleteBuilder struct {
+	enc   *Encoder
+	i     interface{}
+	rv    string
+	rcc   string
+	conds []Condition
+}
+
+// Delete begins a DeleteItemInput for the item with the key of i
+func Delete(i interface{}) *DeleteBuilder {
+	return std.Delete(i)
+}
+
+// ReturnValues asks dynamoDB to return the deleted item, either NONE
+// (the default) or ALL_OLD. Decode the returned attributes with
+// UnmarshalReturnValues.
+func (b *DeleteBuilder) ReturnValues(rv string) *DeleteBuilder {
+	b.rv = rv
+	return b
+}
+
+// ReturnConsumedCapacity asks dynamoDB to report the capacity the delete
+// consumes, TOTAL or by INDEXES, or NONE (the default).
+func (b *DeleteBuilder) ReturnConsumedCapacity(rcc string) *DeleteBuilder {
+	b.rcc = rcc
+	return b
+}
+
+// If conditions the delete on the AND of conds holding for the item,
+// see ApplyCondition.
+func (b *DeleteBuilder) If(conds ...Condition) *DeleteBuilder {
+	b.conds = append(b.conds, conds...)
+	return b
+}
+
+// Input builds the DeleteItemInput
+func (b *DeleteBuilder) Input() (*dynamodb.DeleteItemInput, error) {
+	if err := checkReturnValues("DeleteItem", b.rv, dynamodb.ReturnValueAllOld); err != nil {
+		return nil, err
+	}
+	if err := checkConsumedCapacity(b.rcc); err != nil {
+		return nil, err
+	}
+	key, err := b.enc.KeyMap(b.i)
+	if err != nil {
+		return nil, err
+	}
+	tn, err := b.enc.tableName(reflect.TypeOf(b.i))
+	if err != nil {
+		return nil, err
+	}
+	di := &dynamodb.DeleteItemInput{
+		TableName: &tn,
+		Key:       key,
+	}
+	if b.rv != "" {
+		di.ReturnValues = &b.rv
+	}
+	if b.rcc != "" {
+		di.ReturnConsumedCapacity = &b.rcc
+	}
+	if err := applyCondition(di, b.i, b.enc.namer, b.conds); err != nil {
+		return nil, err
+	}
+	return di, nil
+}
+
+// UnmarshalReturnValues decodes the Attributes returned by a PutItem,
+// UpdateItem or DeleteItem call made with ReturnValues into the struct
+// i. out is the *dynamodb.PutItemOutput, *dynamodb.UpdateItemOutput or
+// *dynamodb.DeleteItemOutput of the call. When nothing was returned (the
+// item didn't exist, or ReturnValues was NONE) i is left untouched.
+func UnmarshalReturnValues(out interface{}, i interface{}) error {
+	var attrs map[string]*dynamodb.AttributeValue
+	switch o := out.(type) {
+	case *dynamodb.PutItemOutput:
+		attrs = o.Attributes
+	case *dynamodb.UpdateItemOutput:
+		attrs = o.Attributes
+	case *dynamodb.DeleteItemOutput:
+		attrs = o.Attributes
+	default:
+		return &UnsupportedOutputError{reflect.TypeOf(out)}
+	}
+	if len(attrs) == 0 {
+		return nil
+	}
+	return Unmarshal(attrs, i)
+}
+
+// rv must be empty, NONE, or one of allowed
+func checkReturnValues(op, rv string, allowed ...string) error {
+	if rv == "" || rv == dynamodb.ReturnValueNone {
+		return nil
+	}
+	for _, a := range allowed {
+		if rv == a {
+			return nil
+		}
+	}
+	return &ReturnValuesError{op, rv}
+}
@@ -0,0 +1,102 @@
+// Copyright 2016 Appittome. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynaGo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestReturnValues(t *testing.T) {
+	pi, err := Put(usr0).ReturnValues(dynamodb.ReturnValueAllOld).Input()
+	if err != nil || *pi.ReturnValues != dynamodb.ReturnValueAllOld {
+		t.Errorf("failed: put ReturnValues %v %v", pi, err)
+	}
+	ui, err := Update(usr0).ReturnValues(dynamodb.ReturnValueUpdatedNew).Input()
+	if err != nil || *ui.ReturnValues != dynamodb.ReturnValueUpdatedNew {
+		t.Errorf("failed: update ReturnValues %v %v", ui, err)
+	}
+	di, err := Delete(&usr0).ReturnValues(dynamodb.ReturnValueAllOld).Input()
+	if err != nil || *di.ReturnValues != dynamodb.ReturnValueAllOld || *di.Key["UserId"].S != "1000" {
+		t.Errorf("failed: delete ReturnValues %v %v", di, err)
+	}
+	if _, err := Put(usr0).ReturnValues(dynamodb.ReturnValueAllNew).Input(); err == nil {
+		t.Error("failed: expected error for PutItem with ALL_NEW")
+	}
+	if pi, _ := Put(usr0).Input(); pi.ReturnValues != nil {
+		t.Error("failed: ReturnValues set by default")
+	}
+
+	var old Usr
+	out := &dynamodb.DeleteItemOutput{Attributes: Marshal(usr1).Item}
+	if err := UnmarshalReturnValues(out, &old); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	if !reflect.DeepEqual(old, usr1) {
+		t.Errorf("failed: decoded %+v", old)
+	}
+	if err := UnmarshalReturnValues(&dynamodb.UpdateItemOutput{}, &old); err != nil || old.Id != usr1.Id {
+		t.Errorf("failed: empty attributes changed destination %v", err)
+	}
+	if err := UnmarshalReturnValues(&dynamodb.GetItemOutput{}, &old); err == nil {
+		t.Error("failed: expected error for GetItemOutput")
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	s := &stubDynamo{}
+	m := Message{SessId: "abc", Timestamp: 1234, Body: "first"}
+	if err := Upsert(s, m, "Body"); err != nil {
+		t.Fatalf("failed: insert %s", err)
+	}
+	if len(s.putIn) != 1 || len(s.updateIn) != 0 {
+		t.Fatalf("failed: insert made %d puts, %d updates", len(s.putIn), len(s.updateIn))
+	}
+	if *s.putIn[0].ConditionExpression != "attribute_not_exists(#n0)" || *s.putIn[0].ExpressionAttributeNames["#n0"] != "SessionId" {
+		t.Errorf("failed: put condition %v %v", *s.putIn[0].ConditionExpression, s.putIn[0].ExpressionAttributeNames)
+	}
+
+	m.Body = "second"
+	if err := Upsert(s, &m, "Body"); err != nil {
+		t.Fatalf("failed: update %s", err)
+	}
+	if len(s.putIn) != 2 || len(s.updateIn) != 1 {
+		t.Fatalf("failed: update made %d puts, %d updates", len(s.putIn), len(s.updateIn))
+	}
+	ui := s.updateIn[0]
+	if *ui.UpdateExpression != "SET #n0 = :v0" || *ui.ExpressionAttributeValues[":v0"].S != "second" {
+		t.Errorf("failed: update %s %v", *ui.UpdateExpression, ui.ExpressionAttributeValues)
+	}
+
+	if err := Upsert(s, m); err != nil || len(s.updateIn) != 1 {
+		t.Errorf("failed: upsert without fields %v, %d updates", err, len(s.updateIn))
+	}
+}
+
+func TestEncoderUpsert(t *testing.T) {
+	type Visit struct {
+		VisitId   string `dynaGo:",HASH"`
+		CreatedAt int64
+	}
+	enc := NewEncoder().WithPrefix("tenant")
+	enc.SetNameTransformer(snakeCase)
+	s := &stubDynamo{}
+	v := Visit{VisitId: "v1", CreatedAt: 10}
+	if err := enc.Upsert(s, v, "CreatedAt"); err != nil {
+		t.Fatalf("failed: %s", err)
+	}
+	pi := s.putIn[0]
+	if *pi.TableName != "tenant_Visits" {
+		t.Errorf("failed: put table %s", *pi.TableName)
+	}
+	if _, ok := pi.Item["created_at"]; !ok {
+		t.Errorf("failed: put item %v", pi.Item)
+	}
+	if *pi.ConditionExpression != "attribute_not_exists(#n0)" || *pi.ExpressionAttributeNames["#n0"] != "visit_id" {
+		t.Errorf("failed: put condition %s %v", *pi.ConditionExpression, pi.ExpressionAttributeNames)
+	}
+}